@@ -2,7 +2,6 @@ package internal
 
 import (
 	"fmt"
-	"log"
 	"math"
 
 	"github.com/sjberman/golang-ray-tracer/pkg/base"
@@ -15,6 +14,12 @@ import (
 
 const divisionThreshold = 2
 
+// number of times the triangles of a displaced shape are split if none is given.
+const defaultShapeSubdivisions = 4
+
+// number of samples in the pixels refined by adaptive anti-aliasing if the camera has none.
+const defaultAdaptiveSamples = 16
+
 // DeDupe removes duplicate Objects from the objList.
 func DeDupe(
 	objList []object.Object,
@@ -39,8 +44,21 @@ func DeDupe(
 
 // CreateCamera builds a camera object using the spec.
 func CreateCamera(cam *schema.Camera) *scene.Camera {
+	projection := scene.PerspectiveProjection
+	if cam.Projection != nil {
+		projection = *cam.Projection
+	}
 	fov := cam.FieldOfView * math.Pi / 180
-	camera := scene.NewCamera(cam.Width, cam.Height, fov)
+
+	var camera *scene.Camera
+	switch projection {
+	case scene.OrthographicProjection:
+		camera = scene.NewOrthographicCamera(cam.Width, cam.Height, *cam.ViewWidth)
+	case scene.EquirectangularProjection, scene.FisheyeProjection, scene.CylindricalProjection:
+		camera = scene.NewPanoramicCamera(cam.Width, cam.Height, projection, fov)
+	default:
+		camera = scene.NewCamera(cam.Width, cam.Height, fov)
+	}
 
 	from := base.NewPoint(cam.From[0], cam.From[1], cam.From[2])
 	to := base.NewPoint(cam.To[0], cam.To[1], cam.To[2])
@@ -48,23 +66,217 @@ func CreateCamera(cam *schema.Camera) *scene.Camera {
 
 	camera.SetTransform(base.ViewTransform(from, to, up))
 
+	var samples int
+	if cam.Samples != nil {
+		samples = *cam.Samples
+	} else if cam.AdaptiveThreshold != nil {
+		samples = defaultAdaptiveSamples
+	}
+	if samples > 0 {
+		sampling := scene.JitteredSampling
+		if cam.Sampling != nil {
+			sampling = *cam.Sampling
+		}
+		camera.SetSampling(samples, sampling)
+	}
+
+	if cam.Shutter != nil {
+		camera.SetShutter(cam.Shutter[0], cam.Shutter[1])
+	}
+
+	if cam.Aperture != nil {
+		focalDistance := to.Subtract(from).Magnitude()
+		if cam.FocalDistance != nil {
+			focalDistance = *cam.FocalDistance
+		}
+		var blades int
+		if cam.ApertureBlades != nil {
+			blades = *cam.ApertureBlades
+		}
+		camera.SetFocus(*cam.Aperture, focalDistance, blades)
+	}
+
 	return camera
 }
 
+// CreateWorld builds the world from its lights and objects, using the render settings, background,
+// and fog of the spec.
+func CreateWorld(
+	render *schema.Render,
+	background *schema.Background,
+	fog *schema.Fog,
+	lights []scene.Light,
+	objects []object.Object,
+) (*scene.World, error) {
+	world := scene.NewWorld(lights, objects)
+	if background != nil {
+		pattern, err := getBackground(background)
+		if err != nil {
+			return nil, err
+		}
+		lighting := background.Lighting != nil && *background.Lighting
+		world.SetBackground(pattern, lighting)
+	}
+	if fog != nil {
+		world.SetFog(fog.Density, image.NewColor(fog.Color[0], fog.Color[1], fog.Color[2]))
+		if fog.Scattering != nil {
+			world.SetFogScattering(*fog.Scattering)
+		}
+	}
+	if render == nil {
+		return world, nil
+	}
+
+	if render.Integrator != nil {
+		world.SetIntegrator(*render.Integrator)
+	}
+	if render.MaxDepth != nil {
+		world.SetMaxDepth(*render.MaxDepth)
+	}
+	if render.MinContribution != nil {
+		world.SetMinContribution(*render.MinContribution)
+	}
+	var occlusionSamples int
+	var occlusionRadius float64
+	if render.OcclusionSamples != nil {
+		occlusionSamples = *render.OcclusionSamples
+	}
+	if render.OcclusionRadius != nil {
+		occlusionRadius = *render.OcclusionRadius
+	}
+	world.SetAmbientOcclusion(occlusionSamples, occlusionRadius)
+	if render.Pass != nil {
+		world.SetPass(*render.Pass)
+	}
+
+	return world, nil
+}
+
+// returns the background pattern for the spec.
+func getBackground(spec *schema.Background) (image.Pattern, error) {
+	var background image.Pattern
+	switch spec.Type {
+	case "solid":
+		background = scene.NewSolidBackground(image.NewColor(spec.Color[0], spec.Color[1], spec.Color[2]))
+	case "gradient":
+		bottom := image.NewColor(spec.Bottom[0], spec.Bottom[1], spec.Bottom[2])
+		top := image.NewColor(spec.Top[0], spec.Top[1], spec.Top[2])
+		background = scene.NewGradientBackground(bottom, top)
+	case "image":
+		canvas, err := image.ReadFile(*spec.File)
+		if err != nil {
+			return nil, fmt.Errorf("error reading background image '%s': %w", *spec.File, err)
+		}
+		background = scene.NewImageBackground(canvas)
+	default:
+		return nil, fmt.Errorf("unknown background type '%s'", spec.Type)
+	}
+	background.SetTransform(getTransforms(spec.Transform, nil)...)
+
+	return background, nil
+}
+
 // CreateLights builds the light objects using the spec.
-func CreateLights(lights []*schema.Light) []*scene.PointLight {
-	newLights := []*scene.PointLight{}
+func CreateLights(lights []*schema.Light) ([]scene.Light, error) {
+	newLights := []scene.Light{}
+	for _, light := range lights {
+		color := image.NewColor(light.Intensity[0], light.Intensity[1], light.Intensity[2])
+
+		lightType := "point"
+		if light.Type != nil {
+			lightType = *light.Type
+		}
+		var newLight scene.Light
+		switch lightType {
+		case "point":
+			point := base.NewPoint(light.At[0], light.At[1], light.At[2])
+			newLight = scene.NewPointLight(point, color)
+		case "spot":
+			point := base.NewPoint(light.At[0], light.At[1], light.At[2])
+			direction := base.NewVector(light.Direction[0], light.Direction[1], light.Direction[2])
+			var inner float64
+			if light.InnerAngle != nil {
+				inner = *light.InnerAngle * math.Pi / 180
+			}
+			outer := *light.OuterAngle * math.Pi / 180
+			newLight = scene.NewSpotLight(point, direction, inner, outer, color)
+		case "directional":
+			direction := base.NewVector(light.Direction[0], light.Direction[1], light.Direction[2])
+			newLight = scene.NewDirectionalLight(direction, color)
+		default:
+			return nil, fmt.Errorf("unknown light type '%s'", lightType)
+		}
+		if light.Attenuation != nil {
+			newLight.SetAttenuation(getAttenuation(light.Attenuation))
+		}
+		newLights = append(newLights, newLight)
+	}
+
+	return newLights, nil
+}
+
+// CreateAreaLights builds the area light objects using the spec.
+func CreateAreaLights(lights []*schema.AreaLight) ([]scene.Light, error) {
+	newLights := []scene.Light{}
 	for _, light := range lights {
 		point := base.NewPoint(light.At[0], light.At[1], light.At[2])
+		uvec := base.NewVector(light.Uvec[0], light.Uvec[1], light.Uvec[2])
+		vvec := base.NewVector(light.Vvec[0], light.Vvec[1], light.Vvec[2])
 		color := image.NewColor(light.Intensity[0], light.Intensity[1], light.Intensity[2])
-		newLights = append(newLights, scene.NewPointLight(point, color))
+
+		var areaLight *scene.AreaLight
+		switch light.Type {
+		case "rectangle":
+			areaLight = scene.NewAreaLight(point, uvec, light.Usteps, vvec, light.Vsteps, color)
+		case "disk":
+			areaLight = scene.NewDiskLight(point, uvec, light.Usteps, vvec, light.Vsteps, color)
+		default:
+			return nil, fmt.Errorf("unknown area light type '%s'", light.Type)
+		}
+		if light.Jitter != nil {
+			areaLight.SetJitter(*light.Jitter)
+		}
+		if light.Attenuation != nil {
+			areaLight.SetAttenuation(getAttenuation(light.Attenuation))
+		}
+		newLights = append(newLights, areaLight)
+	}
+
+	return newLights, nil
+}
+
+// CreateGeometryLights builds a light for each glowing object that lights the other objects. The
+// children of a group or csg that doesn't glow itself can still light the scene.
+func CreateGeometryLights(objects []object.Object) []scene.Light {
+	newLights := []scene.Light{}
+	for _, obj := range objects {
+		material := obj.GetMaterial()
+		if material.Emission != nil && material.EmissionSamples > 0 {
+			newLights = append(newLights, scene.NewGeometryLight(obj, material.EmissionSamples))
+
+			continue
+		}
+		switch o := obj.(type) {
+		case *object.Group:
+			newLights = append(newLights, CreateGeometryLights(o.Objects)...)
+		case *object.Csg:
+			newLights = append(newLights, CreateGeometryLights(o.Children())...)
+		}
 	}
 
 	return newLights
 }
 
+func getAttenuation(vals []float64) scene.Attenuation {
+	return scene.Attenuation{
+		Constant:  vals[0],
+		Linear:    vals[1],
+		Quadratic: vals[2],
+	}
+}
+
 // CreateShapes builds the shape objects using the spec.
-func CreateShapes(shapes []*schema.Shape) ([]object.Object, map[string]object.Object) {
+func CreateShapes(shapes []*schema.Shape) ([]object.Object, map[string]object.Object, error) {
 	objs := []object.Object{}
 	shapeMap := make(map[string]object.Object)
 	for _, shape := range shapes {
@@ -109,20 +321,42 @@ func CreateShapes(shapes []*schema.Shape) ([]object.Object, map[string]object.Ob
 		if shape.Inherits != nil {
 			parent = shapeMap[*shape.Inherits]
 			if parent == nil {
-				log.Fatalf("Shape '%s' inherits from shape '%s', which must be defined prior.",
+				return nil, nil, fmt.Errorf("shape '%s' inherits from shape '%s', which must be defined prior",
 					shape.Name, *shape.Inherits)
 			}
 			inheritedMaterial = parent.GetMaterial()
 			inheritedTform = parent.GetTransform()
 		}
 
-		obj.SetMaterial(getMaterial(shape.Material, inheritedMaterial))
+		material, err := getMaterial(shape.Material, inheritedMaterial)
+		if err != nil {
+			return nil, nil, fmt.Errorf("shape '%s': %w", shape.Name, err)
+		}
+		obj.SetMaterial(material)
 		obj.SetTransform(getTransforms(shape.Transform, inheritedTform)...)
+		if endTransforms := getEndTransforms(shape.Transform, shape.EndTransform, parent); endTransforms != nil {
+			obj.SetEndTransform(endTransforms...)
+		}
+		if shape.Displacement != nil {
+			// the shape is replaced by a mesh, which is displaced without further subdivision
+			displacement, err := getDisplacement(shape.Displacement, defaultShapeSubdivisions)
+			if err != nil {
+				return nil, nil, fmt.Errorf("shape '%s': %w", shape.Name, err)
+			}
+			mesh, err := object.Tessellate(obj, displacement.Subdivisions)
+			if err != nil {
+				return nil, nil, fmt.Errorf("shape '%s' cannot be displaced: %w", shape.Name, err)
+			}
+			displacement.Subdivisions = 0
+			mesh.Displace(displacement)
+			mesh.Divide(divisionThreshold)
+			obj = mesh
+		}
 		objs = append(objs, obj)
 		shapeMap[shape.Name] = obj
 	}
 
-	return objs, shapeMap
+	return objs, shapeMap, nil
 }
 
 // CreateGroupsAndCSGs builds the group and csg objects using the spec.
@@ -130,7 +364,7 @@ func CreateGroupsAndCSGs(
 	sceneStruct schema.RayTracerScene,
 	shapeMap,
 	objMap map[string]object.Object,
-) ([]object.Object, []string, []string) {
+) ([]object.Object, []string, []string, error) {
 	var usedShapes, usedOBJGroups, usedGroups []string
 	groupMap := make(map[string]object.Object)
 	csgMap := make(map[string]object.Object)
@@ -145,14 +379,27 @@ func CreateGroupsAndCSGs(
 
 	// Create CSGs
 	for _, csg := range sceneStruct.Csgs {
-		left := getChild(&csg.LeftChild, shapeMap, objMap, groupMap, nil, &usedShapes, &usedOBJGroups, &usedGroups)
-		right := getChild(&csg.RightChild, shapeMap, objMap, groupMap, nil, &usedShapes, &usedOBJGroups, &usedGroups)
+		left, err := getChild(&csg.LeftChild, shapeMap, objMap, groupMap, nil, &usedShapes, &usedOBJGroups, &usedGroups)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("csg '%s': %w", csg.Name, err)
+		}
+		right, err := getChild(&csg.RightChild, shapeMap, objMap, groupMap, nil, &usedShapes, &usedOBJGroups, &usedGroups)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("csg '%s': %w", csg.Name, err)
+		}
 
 		newCSG := object.NewCsg(csg.Operation, left, right)
 		if csg.Material != nil {
-			newCSG.SetMaterial(getMaterial(csg.Material, nil))
+			material, err := getMaterial(csg.Material, nil)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("csg '%s': %w", csg.Name, err)
+			}
+			newCSG.SetMaterial(material)
 		}
 		newCSG.SetTransform(getTransforms(csg.Transform, nil)...)
+		if csg.EndTransform != nil {
+			newCSG.SetEndTransform(getTransforms(csg.EndTransform, nil)...)
+		}
 		newCSG.Divide(divisionThreshold)
 		csgMap[csg.Name] = newCSG
 		csgs = append(csgs, newCSG)
@@ -163,26 +410,36 @@ func CreateGroupsAndCSGs(
 		toAdd := make([]object.Object, 0, len(grp.Children))
 		group, ok := groupMap[grp.Name].(*object.Group)
 		if !ok {
-			log.Fatal("failed type assertion for Group")
+			return nil, nil, nil, fmt.Errorf("group '%s' failed type assertion for Group", grp.Name)
 		}
 
 		for _, child := range grp.Children {
 			child := child
-			childObj := getChild(&child, shapeMap, objMap, groupMap, csgMap, &usedShapes, &usedOBJGroups, &usedGroups)
+			childObj, err := getChild(&child, shapeMap, objMap, groupMap, csgMap, &usedShapes, &usedOBJGroups, &usedGroups)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("group '%s': %w", grp.Name, err)
+			}
 			toAdd = append(toAdd, childObj)
 		}
 		group.Add(toAdd...)
 		if grp.Material != nil {
-			group.SetMaterial(getMaterial(grp.Material, nil))
+			material, err := getMaterial(grp.Material, nil)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("group '%s': %w", grp.Name, err)
+			}
+			group.SetMaterial(material)
 		}
 		group.SetTransform(getTransforms(grp.Transform, nil)...)
+		if grp.EndTransform != nil {
+			group.SetEndTransform(getTransforms(grp.EndTransform, nil)...)
+		}
 		group.Divide(divisionThreshold)
 		groups = append(groups, group)
 	}
 	groups = DeDupe(append(groups, csgs...), groupMap, usedGroups)
 	groups = DeDupe(groups, csgMap, usedGroups)
 
-	return groups, usedShapes, usedOBJGroups
+	return groups, usedShapes, usedOBJGroups, nil
 }
 
 func getChild(
@@ -194,13 +451,14 @@ func getChild(
 	usedShapes,
 	usedOBJGroups,
 	usedGroups *[]string,
-) object.Object {
-	var childObject object.Object
+) (object.Object, error) {
+	var childObject, original object.Object
 	var inheritedMaterial *object.Material
 	var inheritedTform *base.Matrix
 
 	if o, ok := shapeMap[child.Name]; ok {
 		childObject = o.DeepCopy()
+		original = o
 		*usedShapes = append(*usedShapes, child.Name)
 		inheritedMaterial = o.GetMaterial()
 		inheritedTform = o.GetTransform()
@@ -208,6 +466,7 @@ func getChild(
 	if csgMap != nil {
 		if o, ok := csgMap[child.Name]; ok {
 			childObject = o.DeepCopy()
+			original = o
 			*usedGroups = append(*usedGroups, child.Name)
 			inheritedMaterial = o.GetMaterial()
 			inheritedTform = o.GetTransform()
@@ -216,6 +475,7 @@ func getChild(
 	if groupMap != nil {
 		if o, ok := groupMap[child.Name]; ok {
 			childObject = o.DeepCopy()
+			original = o
 			*usedGroups = append(*usedGroups, child.Name)
 			inheritedMaterial = o.GetMaterial()
 			inheritedTform = o.GetTransform()
@@ -223,19 +483,27 @@ func getChild(
 	}
 	if o, ok := objMap[child.Name]; ok {
 		childObject = o.DeepCopy()
+		original = o
 		*usedOBJGroups = append(*usedOBJGroups, child.Name)
 		inheritedMaterial = o.GetMaterial()
 		inheritedTform = o.GetTransform()
 	}
 
 	if child.Material != nil {
-		childObject.SetMaterial(getMaterial(child.Material, inheritedMaterial))
+		material, err := getMaterial(child.Material, inheritedMaterial)
+		if err != nil {
+			return nil, fmt.Errorf("child '%s': %w", child.Name, err)
+		}
+		childObject.SetMaterial(material)
 	}
 	if child.Transform != nil {
 		childObject.SetTransform(getTransforms(child.Transform, inheritedTform)...)
 	}
+	if endTransforms := getEndTransforms(child.Transform, child.EndTransform, original); endTransforms != nil {
+		childObject.SetEndTransform(endTransforms...)
+	}
 
-	return childObject
+	return childObject, nil
 }
 
 // ParseOBJ parses the supplied OBJ files and creates groups.
@@ -250,9 +518,23 @@ func ParseOBJ(files []*schema.File) ([]object.Object, map[string]object.Object,
 		}
 		group := parser.GetGroup()
 		if grp.Material != nil {
-			group.SetMaterial(getMaterial(grp.Material, nil))
+			material, err := getMaterial(grp.Material, nil)
+			if err != nil {
+				return nil, nil, fmt.Errorf("OBJ file '%s': %w", grp.Name, err)
+			}
+			group.SetMaterial(material)
 		}
 		group.SetTransform(getTransforms(grp.Transform, nil)...)
+		if grp.EndTransform != nil {
+			group.SetEndTransform(getTransforms(grp.EndTransform, nil)...)
+		}
+		if grp.Displacement != nil {
+			displacement, err := getDisplacement(grp.Displacement, 0)
+			if err != nil {
+				return nil, nil, fmt.Errorf("OBJ file '%s': %w", grp.Name, err)
+			}
+			group.Displace(displacement)
+		}
 		group.Divide(divisionThreshold)
 		groups = append(groups, group)
 		objMap[grp.Name] = group
@@ -261,13 +543,13 @@ func ParseOBJ(files []*schema.File) ([]object.Object, map[string]object.Object,
 	return groups, objMap, nil
 }
 
-func getMaterial(material *schema.Material, inheritedMaterial *object.Material) *object.Material {
+func getMaterial(material *schema.Material, inheritedMaterial *object.Material) (*object.Material, error) {
 	objMaterial := object.DefaultMaterial
 	if inheritedMaterial != nil {
 		objMaterial = *inheritedMaterial
 	}
 	if material == nil {
-		return &objMaterial
+		return &objMaterial, nil
 	}
 
 	if material.Color != nil {
@@ -275,23 +557,10 @@ func getMaterial(material *schema.Material, inheritedMaterial *object.Material)
 		objMaterial.Color = image.NewColor(rgb[0], rgb[1], rgb[2])
 	}
 	if material.Pattern != nil {
-		var pattern image.Pattern
-		rgb1 := material.Pattern.Color1
-		rgb2 := material.Pattern.Color2
-		color1 := image.NewColor(rgb1[0], rgb1[1], rgb1[2])
-		color2 := image.NewColor(rgb2[0], rgb2[1], rgb2[2])
-
-		switch material.Pattern.Type {
-		case "checker":
-			pattern = image.NewCheckerPattern(color1, color2)
-		case "gradient":
-			pattern = image.NewGradientPattern(color1, color2)
-		case "ring":
-			pattern = image.NewRingPattern(color1, color2)
-		case "stripe":
-			pattern = image.NewStripePattern(color1, color2)
+		pattern, err := getPattern(material.Pattern)
+		if err != nil {
+			return nil, err
 		}
-		pattern.SetTransform(getTransforms(material.Pattern.Transform, nil)...)
 		objMaterial.Pattern = pattern
 	}
 	if material.Ambient != nil {
@@ -306,6 +575,18 @@ func getMaterial(material *schema.Material, inheritedMaterial *object.Material)
 	if material.Shininess != nil {
 		objMaterial.Shininess = *material.Shininess
 	}
+	if material.Shader != nil {
+		if _, ok := scene.GetShader(*material.Shader); !ok {
+			return nil, fmt.Errorf("unknown shader '%s'", *material.Shader)
+		}
+		objMaterial.Shader = *material.Shader
+	}
+	if material.Metallic != nil {
+		objMaterial.Metallic = *material.Metallic
+	}
+	if material.Roughness != nil {
+		objMaterial.Roughness = *material.Roughness
+	}
 	if material.Reflective != nil {
 		objMaterial.Reflective = *material.Reflective
 	}
@@ -318,8 +599,102 @@ func getMaterial(material *schema.Material, inheritedMaterial *object.Material)
 	if material.Shadow != nil {
 		objMaterial.Shadow = *material.Shadow
 	}
+	if material.Emission != nil {
+		rgb := *material.Emission
+		objMaterial.Emission = image.NewColor(rgb[0], rgb[1], rgb[2])
+	}
+	if material.EmissionSamples != nil {
+		objMaterial.EmissionSamples = *material.EmissionSamples
+	}
+	if material.Volume != nil {
+		rgb := material.Volume.Color
+		objMaterial.Volume = &object.Volume{
+			Density: material.Volume.Density,
+			Color:   image.NewColor(rgb[0], rgb[1], rgb[2]),
+		}
+	}
 
-	return &objMaterial
+	return &objMaterial, nil
+}
+
+func getPattern(spec *schema.Pattern) (image.Pattern, error) {
+	var pattern image.Pattern
+	if spec.Type == "image" {
+		canvas, err := image.ReadFile(*spec.File)
+		if err != nil {
+			return nil, fmt.Errorf("error reading pattern image '%s': %w", *spec.File, err)
+		}
+		mapping := image.PlanarMapping
+		if spec.Mapping != nil {
+			mapping = *spec.Mapping
+		}
+		pattern = image.NewImagePattern(canvas, mapping)
+	} else {
+		rgb1 := spec.Color1
+		rgb2 := spec.Color2
+		color1 := image.NewColor(rgb1[0], rgb1[1], rgb1[2])
+		color2 := image.NewColor(rgb2[0], rgb2[1], rgb2[2])
+
+		switch spec.Type {
+		case "checker":
+			pattern = image.NewCheckerPattern(color1, color2)
+		case "gradient":
+			pattern = image.NewGradientPattern(color1, color2)
+		case "ring":
+			pattern = image.NewRingPattern(color1, color2)
+		case "stripe":
+			pattern = image.NewStripePattern(color1, color2)
+		}
+	}
+	pattern.SetTransform(getTransforms(spec.Transform, nil)...)
+
+	return pattern, nil
+}
+
+// returns the displacement for the spec, with the default number of subdivisions.
+func getDisplacement(spec *schema.Displacement, defaultSubdivisions int) (object.Displacement, error) {
+	pattern, err := getPattern(spec.Pattern)
+	if err != nil {
+		return object.Displacement{}, err
+	}
+	displacement := object.Displacement{
+		Pattern:      pattern,
+		Scale:        spec.Scale,
+		Midlevel:     0.5,
+		Subdivisions: defaultSubdivisions,
+	}
+	if spec.Midlevel != nil {
+		displacement.Midlevel = *spec.Midlevel
+	}
+	if spec.Subdivisions != nil {
+		displacement.Subdivisions = *spec.Subdivisions
+	}
+
+	return displacement, nil
+}
+
+// returns the transforms at the end of an object's motion, or nil if it doesn't move. An object
+// that inherits from a moving object moves with it, and its own transforms apply at both the start
+// and end of the motion unless it has end transforms.
+func getEndTransforms(
+	transforms, endTransforms []*schema.Transform,
+	inherited object.Object,
+) []*base.Matrix {
+	var inheritedTform *base.Matrix
+	if inherited != nil {
+		inheritedTform = inherited.GetEndTransform()
+	}
+	if endTransforms == nil {
+		if inheritedTform == nil {
+			return nil
+		}
+		endTransforms = transforms
+	}
+	if inheritedTform == nil && inherited != nil {
+		inheritedTform = inherited.GetTransform()
+	}
+
+	return getTransforms(endTransforms, inheritedTform)
 }
 
 func getTransforms(transforms []*schema.Transform, inheritedTform *base.Matrix) []*base.Matrix {
@@ -343,15 +718,9 @@ func getTransforms(transforms []*schema.Transform, inheritedTform *base.Matrix)
 			x := transform.Values[0] * math.Pi / 180
 			y := transform.Values[1] * math.Pi / 180
 			z := transform.Values[2] * math.Pi / 180
-			if transform.Values[0] != 0 {
-				t = append(t, base.RotateX(x))
-			}
-			if transform.Values[1] != 0 {
-				t = append(t, base.RotateY(y))
-			}
-			if transform.Values[2] != 0 {
-				t = append(t, base.RotateZ(z))
-			}
+			// every axis is kept, even without any rotation, so that the rotations of a moving
+			// object line up with the ones in its end transforms
+			t = append(t, base.RotateX(x), base.RotateY(y), base.RotateZ(z))
 		case "shear":
 			xy := transform.Values[0] * math.Pi / 180
 			xz := transform.Values[1] * math.Pi / 180
@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/object"
+	"github.com/sjberman/golang-ray-tracer/schema"
+)
+
+func TestCreateCamera_Adaptive(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	spec := func() *schema.Camera {
+		return &schema.Camera{
+			Width:       11,
+			Height:      11,
+			FieldOfView: 90,
+			From:        []float64{0, 0, -5},
+			To:          []float64{0, 0, 0},
+			Up:          []float64{0, 1, 0},
+		}
+	}
+	light := scene.NewPointLight(base.NewPoint(-10, 10, -10), image.White)
+	w := scene.NewWorld([]scene.Light{light}, []object.Object{object.NewSphere()})
+	plain := scene.Render(CreateCamera(spec()), w)
+
+	// without any samples, the refined pixels still get more than one
+	threshold := 0.1
+	adaptiveSpec := spec()
+	adaptiveSpec.AdaptiveThreshold = &threshold
+	canvas, refined := scene.RenderAdaptive(CreateCamera(adaptiveSpec), w, threshold, scene.RenderOptions{})
+	g.Expect(refined).To(BeNumerically(">", 0))
+	changed := 0
+	for y := range 11 {
+		for x := range 11 {
+			if !canvas.PixelAt(x, y).Equals(plain.PixelAt(x, y)) {
+				changed++
+			}
+		}
+	}
+	g.Expect(changed).To(BeNumerically(">", 0))
+
+	// a single sample is kept if it's asked for
+	samples := 1
+	adaptiveSpec.Samples = &samples
+	canvas, _ = scene.RenderAdaptive(CreateCamera(adaptiveSpec), w, threshold, scene.RenderOptions{})
+	g.Expect(canvas).To(Equal(plain))
+}
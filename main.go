@@ -1,17 +1,24 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ghodss/yaml"
 	"github.com/sjberman/golang-ray-tracer/internal"
+	"github.com/sjberman/golang-ray-tracer/pkg/distributed"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
 	"github.com/sjberman/golang-ray-tracer/pkg/scene"
 	"github.com/sjberman/golang-ray-tracer/pkg/scene/object"
 	"github.com/sjberman/golang-ray-tracer/schema"
@@ -22,6 +29,28 @@ var (
 	schemaFile = flag.String("schema", "schema/schema.json", "Relative path to the schema.json file")
 	sceneFile  = flag.String("scene", "", "JSON or YAML file containing scene info")
 	outputFile = flag.String("output", "image.ppm", "Image output file (.ppm)")
+	samples    = flag.Int("samples", 0, "Rays per pixel for anti-aliasing (overrides the scene file)")
+	sampling   = flag.String("sampling", "", "Placement of rays within a pixel: grid, jittered, or random "+
+		"(overrides the scene file)")
+	adaptive = flag.Float64("adaptive", 0, "Only anti-alias pixels that differ from a neighbor by more than "+
+		"this threshold, with 16 samples if none are given (overrides the scene file)")
+	workers   = flag.Int("workers", 0, "Number of tiles rendered at once, on each worker if remote (default is the number of CPUs)")
+	tileSize  = flag.Int("tile-size", scene.DefaultTileSize, "Width and height of a rendered tile in pixels")
+	tileOrder = flag.String("tile-order", scene.ScanlineOrder, "Order that tiles are rendered in: scanline or spiral")
+	progress  = flag.Bool("progress", false, "Print the rendering progress and estimated time remaining")
+	passes    = flag.Int("passes", 0, "Render progressively, adding a sample to every pixel in each of this many "+
+		"passes (overrides the camera's samples)")
+	snapshotInterval = flag.Duration("snapshot-interval", 10*time.Second, "Time between writing the output file "+
+		"during a progressive render")
+	crop = flag.String("crop", "", "Only render the region x0,y0,x1,y1 in pixels (e.g. 100,50,400,300), or as "+
+		"fractions of the image if any value has a decimal point (e.g. 0.25,0.25,0.75,0.75)")
+	cropOutput = flag.Bool("crop-output", false, "Write only the cropped region, instead of the full image "+
+		"with the rest left black")
+	pass   = flag.String("pass", "", "What is rendered: shaded, or occlusion for compositing (overrides the scene file)")
+	remote = flag.String("remote", "", "Comma separated addresses (host:port) of worker processes to render on, "+
+		"each started with 'worker --listen host:port' from the same directory")
+	remoteTimeout = flag.Duration("remote-timeout", 5*time.Minute, "Longest a remote worker may take to render a "+
+		"tile before its tiles are given to the other workers (0 means no limit)")
 )
 
 func parseArgs() {
@@ -41,19 +70,108 @@ func parseArgs() {
 	if !strings.HasSuffix(*sceneFile, ".json") && !strings.HasSuffix(*sceneFile, ".yaml") {
 		log.Fatal("scene file must be of type .json or .yaml")
 	}
+
+	switch *sampling {
+	case "", scene.GridSampling, scene.JitteredSampling, scene.RandomSampling:
+	default:
+		log.Fatalf("sampling must be one of %s, %s, or %s", scene.GridSampling, scene.JitteredSampling, scene.RandomSampling)
+	}
+
+	if *pass != "" && *pass != scene.ShadedPass && *pass != scene.OcclusionPass {
+		log.Fatalf("pass must be one of %s or %s", scene.ShadedPass, scene.OcclusionPass)
+	}
+
+	if *tileOrder != scene.ScanlineOrder && *tileOrder != scene.SpiralOrder {
+		log.Fatalf("tile order must be one of %s or %s", scene.ScanlineOrder, scene.SpiralOrder)
+	}
+
+	if *remote != "" && *passes > 0 {
+		log.Fatal("progressive rendering can't be used with remote workers")
+	}
+}
+
+// Parses the crop argument for an image of the given size.
+func parseCrop(width, height int) scene.Crop {
+	values := strings.Split(*crop, ",")
+	if len(values) != 4 {
+		log.Fatalf("crop must be four values: x0,y0,x1,y1")
+	}
+
+	var c scene.Crop
+	if strings.Contains(*crop, ".") {
+		var corners [4]float64
+		for i, v := range values {
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil || f < 0 || f > 1 {
+				log.Fatalf("crop fraction '%s' must be a number from 0 to 1", v)
+			}
+			corners[i] = f
+		}
+		c = scene.NormalizedCrop(width, height, corners[0], corners[1], corners[2], corners[3])
+	} else {
+		var corners [4]int
+		for i, v := range values {
+			n, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil || n < 0 {
+				log.Fatalf("crop pixel '%s' must be a non-negative integer", v)
+			}
+			corners[i] = n
+		}
+		c = scene.Crop{X0: corners[0], Y0: corners[1], X1: corners[2], Y1: corners[3]}
+	}
+
+	if c.X0 >= c.X1 || c.Y0 >= c.Y1 || c.X1 > width || c.Y1 > height {
+		log.Fatalf("crop %d,%d,%d,%d must be a non-empty region within the %dx%d image",
+			c.X0, c.Y0, c.X1, c.Y1, width, height)
+	}
+
+	return c
+}
+
+// Overrides the scene file settings with any supplied command line arguments.
+func applyArgs(sceneStruct *schema.RayTracerScene) {
+	if *samples > 0 {
+		sceneStruct.Camera.Samples = samples
+	}
+	if *sampling != "" {
+		sceneStruct.Camera.Sampling = sampling
+	}
+	if *adaptive > 0 {
+		sceneStruct.Camera.AdaptiveThreshold = adaptive
+	}
+	if *pass != "" {
+		if sceneStruct.Render == nil {
+			sceneStruct.Render = &schema.Render{}
+		}
+		sceneStruct.Render.Pass = pass
+	}
 }
 
 // Builds all of the objects defined in the scene.
-func getSceneObjects(sceneStruct schema.RayTracerScene) (*scene.Camera, []*scene.PointLight, []object.Object) {
+func getSceneObjects(sceneStruct schema.RayTracerScene) (*scene.Camera, []scene.Light, []object.Object, error) {
 	camera := internal.CreateCamera(sceneStruct.Camera)
-	lights := internal.CreateLights(sceneStruct.Lights)
-	shapes, shapeMap := internal.CreateShapes(sceneStruct.Shapes)
+	lights, err := internal.CreateLights(sceneStruct.Lights)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	areaLights, err := internal.CreateAreaLights(sceneStruct.AreaLights)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	lights = append(lights, areaLights...)
+	shapes, shapeMap, err := internal.CreateShapes(sceneStruct.Shapes)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	objGroups, objMap, err := internal.ParseOBJ(sceneStruct.Files)
 	if err != nil {
-		log.Fatal(err.Error())
+		return nil, nil, nil, err
 	}
 
-	groups, usedShapes, usedOBJGroups := internal.CreateGroupsAndCSGs(sceneStruct, shapeMap, objMap)
+	groups, usedShapes, usedOBJGroups, err := internal.CreateGroupsAndCSGs(sceneStruct, shapeMap, objMap)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
 	// De-dupe any objects that are included in a group definition
 	shapes = internal.DeDupe(shapes, shapeMap, usedShapes)
@@ -61,8 +179,79 @@ func getSceneObjects(sceneStruct schema.RayTracerScene) (*scene.Camera, []*scene
 
 	objects := append(shapes, objGroups...)
 	objects = append(objects, groups...)
+	lights = append(lights, internal.CreateGeometryLights(objects)...)
+
+	return camera, lights, objects, nil
+}
+
+// Loads a scene sent by a coordinator, which has already been validated.
+func loadRemoteScene(description []byte) (*scene.Camera, *scene.World, error) {
+	var sceneStruct schema.RayTracerScene
+	if err := json.Unmarshal(description, &sceneStruct); err != nil {
+		return nil, nil, fmt.Errorf("error unmarshaling scene JSON: %w", err)
+	}
+	camera, lights, objects, err := getSceneObjects(sceneStruct)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	world, err := internal.CreateWorld(sceneStruct.Render, sceneStruct.Background, sceneStruct.Fog, lights, objects)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return camera, world, nil
+}
+
+// Runs a worker process, which renders tiles for a coordinator started with the remote argument.
+func runWorker(args []string) {
+	flags := flag.NewFlagSet("worker", flag.ExitOnError)
+	listen := flags.String("listen", "127.0.0.1:7001", "Address (host:port) to listen for a coordinator on")
+	if err := flags.Parse(args); err != nil {
+		log.Fatalf("error parsing worker arguments: %v", err)
+	}
+
+	fmt.Println("Worker listening on", *listen)
+	if err := http.ListenAndServe(*listen, distributed.NewWorker(loadRemoteScene)); err != nil {
+		log.Fatalf("error running worker: %v", err)
+	}
+}
+
+// Renders the scene on the remote workers, after any command line arguments have been applied to it.
+func renderRemote(
+	ctx context.Context,
+	sceneStruct schema.RayTracerScene,
+	opts scene.RenderOptions,
+) (*image.Canvas, error) {
+	description, err := json.Marshal(sceneStruct)
+	if err != nil {
+		log.Fatalf("error marshaling scene JSON: %v\n", err)
+	}
+	addresses := strings.Split(*remote, ",")
+	for i, address := range addresses {
+		addresses[i] = strings.TrimSpace(address)
+	}
 
-	return camera, lights, objects
+	return distributed.Render(ctx, description, sceneStruct.Camera.Width, sceneStruct.Camera.Height, addresses,
+		distributed.Options{RenderOptions: opts, Timeout: *remoteTimeout})
+}
+
+// Prints the rendering progress on a single line.
+func printProgress(p scene.Progress) {
+	fmt.Fprintf(os.Stderr, "\rRendered %d of %d tiles (%d%%), %s remaining ",
+		p.TilesDone, p.TotalTiles, 100*p.TilesDone/p.TotalTiles, p.Remaining.Round(time.Second))
+	if p.TilesDone == p.TotalTiles {
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+// Writes the image to the output file, with only the cropped region if requested.
+func writeImage(canvas *image.Canvas, crop scene.Crop) error {
+	if *cropOutput && crop != (scene.Crop{}) {
+		canvas = canvas.Crop(crop.X0, crop.Y0, crop.X1, crop.Y1)
+	}
+
+	return canvas.WriteToFile(*outputFile)
 }
 
 func main() {
@@ -70,6 +259,10 @@ func main() {
 	// f1, _ := os.Create("perfFile")
 	// pprof.StartCPUProfile(f1)
 	// defer pprof.StopCPUProfile()
+	if len(os.Args) > 1 && os.Args[1] == "worker" {
+		runWorker(os.Args[2:])
+		return
+	}
 	parseArgs()
 
 	sceneBytes, err := os.ReadFile(*sceneFile)
@@ -103,14 +296,79 @@ func main() {
 	if err != nil {
 		log.Fatalf("error unmarshaling scene JSON: %v\n", err)
 	}
-	camera, lights, objects := getSceneObjects(sceneStruct)
+	applyArgs(&sceneStruct)
+	camera, lights, objects, err := getSceneObjects(sceneStruct)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
 
-	world := scene.NewWorld(lights, objects)
-	canvas := scene.Render(camera, world)
-	err = canvas.WriteToFile(*outputFile)
+	world, err := internal.CreateWorld(sceneStruct.Render, sceneStruct.Background, sceneStruct.Fog, lights, objects)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	opts := scene.RenderOptions{
+		Workers:  *workers,
+		TileSize: *tileSize,
+		Order:    *tileOrder,
+	}
+	if *progress {
+		opts.Progress = printProgress
+	}
+	if *crop != "" {
+		opts.Crop = parseCrop(sceneStruct.Camera.Width, sceneStruct.Camera.Height)
+	}
+	var canvas *image.Canvas
+	// an error from the remote workers, which is reported once the finished tiles are written
+	var remoteErr error
+	if *remote != "" {
+		if sceneStruct.Camera.AdaptiveThreshold != nil {
+			fmt.Println("Adaptive anti-aliasing isn't used with remote workers")
+		}
+		// an interrupt stops the render, and the finished part of the image is still written
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		canvas, err = renderRemote(ctx, sceneStruct, opts)
+		stop()
+		if errors.Is(err, context.Canceled) {
+			fmt.Println("Render interrupted; writing the finished tiles")
+		} else if err != nil {
+			remoteErr = err
+		}
+	} else if *passes > 0 {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		canvas, err = scene.RenderProgressive(ctx, camera, world, scene.ProgressiveOptions{
+			RenderOptions: opts,
+			Passes:        *passes,
+			Interval:      *snapshotInterval,
+			Snapshot: func(snapshot *image.Canvas) {
+				if err := writeImage(snapshot, opts.Crop); err != nil {
+					fmt.Println("error writing snapshot: ", err.Error())
+				}
+			},
+		})
+		stop()
+		if errors.Is(err, context.Canceled) {
+			fmt.Println("Render interrupted; writing the finished passes")
+		}
+	} else if threshold := sceneStruct.Camera.AdaptiveThreshold; threshold != nil {
+		var refined int
+		canvas, refined = scene.RenderAdaptive(camera, world, *threshold, opts)
+		fmt.Printf("Refined %d of %d pixels\n", refined, sceneStruct.Camera.Width*sceneStruct.Camera.Height)
+	} else {
+		// an interrupt stops the render, and the finished part of the image is still written
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		canvas, err = scene.RenderContext(ctx, camera, world, opts)
+		stop()
+		if errors.Is(err, context.Canceled) {
+			fmt.Println("Render interrupted; writing the finished tiles")
+		}
+	}
+	err = writeImage(canvas, opts.Crop)
 	if err != nil {
 		fmt.Println("error writing file: ", err.Error())
 	}
+	if remoteErr != nil {
+		log.Fatalf("error rendering on remote workers: %v", remoteErr)
+	}
 
 	fmt.Println("Total runtime: ", time.Since(startTime).Round(time.Second))
 }
@@ -96,6 +96,21 @@ func (m *Matrix) Transpose() *Matrix {
 	return res
 }
 
+// Interpolate returns the transform that is the fraction t of the way from m to m2. Each transform
+// is split into a translation, rotation, and scale, which are interpolated separately, so that an
+// object turns (the shorter way round) instead of shrinking through the middle of its motion.
+// Only applies to 4x4 affine transforms.
+func (m *Matrix) Interpolate(m2 *Matrix, t float64) *Matrix {
+	if t <= 0 {
+		return m
+	}
+	if t >= 1 {
+		return m2
+	}
+
+	return interpolate(m.decompose(), m2.decompose(), t)
+}
+
 // Inverse returns the inversion of a 4x4 matrix.
 func (m *Matrix) Inverse() *Matrix {
 	s0 := m.data[0][0]*m.data[1][1] - m.data[1][0]*m.data[0][1]
@@ -144,6 +144,26 @@ func TestTranspose(t *testing.T) {
 	g.Expect(Identity.Transpose()).To(Equal(&Identity))
 }
 
+func TestInterpolate(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	start := Translate(0, 0, 0)
+	end := Translate(4, -2, 8)
+	g.Expect(start.Interpolate(end, 0)).To(Equal(start))
+	g.Expect(start.Interpolate(end, 1)).To(Equal(end))
+	g.Expect(start.Interpolate(end, 0.25)).To(Equal(Translate(1, -0.5, 2)))
+
+	g.Expect(Scale(1, 2, 3).Interpolate(Scale(3, 2, 1), 0.5)).To(Equal(Scale(2, 2, 2)))
+
+	// rotations turn instead of shrinking
+	g.Expect(RotateY(0).Interpolate(RotateY(math.Pi/2), 0.5).Equals(RotateY(math.Pi / 4))).To(BeTrue())
+	start = Translate(0, 2, 0).Multiply(Scale(1, 1, 1))
+	end = Translate(4, 2, 0).Multiply(RotateZ(math.Pi / 2)).Multiply(Scale(3, 3, 3))
+	expected := Translate(2, 2, 0).Multiply(RotateZ(math.Pi / 4)).Multiply(Scale(2, 2, 2))
+	g.Expect(start.Interpolate(end, 0.5).Equals(expected)).To(BeTrue())
+}
+
 func TestInverse(t *testing.T) {
 	t.Parallel()
 	g := NewWithT(t)
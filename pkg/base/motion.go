@@ -0,0 +1,260 @@
+package base
+
+import "math"
+
+// The most times the rotation of a transform is refined when it's split into its parts.
+const maxPolarIterations = 100
+
+// Motion is a transform that changes over time, from the product of its start transforms at time 0
+// to the product of its end transforms at time 1. If there are as many start as end transforms,
+// each start transform moves to its end transform on its own (so a rotation after a translation
+// swings an object along an arc around the origin), and otherwise the products are interpolated.
+type Motion struct {
+	end   *Matrix
+	parts []motionPart
+}
+
+// one of the transforms of a Motion, which moves between its start and end parts if it changes.
+type motionPart struct {
+	fixed      *Matrix // nil if the transform changes
+	start, end decomposition
+}
+
+// NewMotion returns a new Motion object.
+func NewMotion(start, end []*Matrix) *Motion {
+	if len(start) != len(end) {
+		start, end = []*Matrix{product(start)}, []*Matrix{product(end)}
+	}
+	parts := make([]motionPart, len(start))
+	for i := range start {
+		if start[i].Equals(end[i]) {
+			parts[i].fixed = start[i]
+		} else {
+			parts[i].start, parts[i].end = start[i].decompose(), end[i].decompose()
+		}
+	}
+
+	return &Motion{end: product(end), parts: parts}
+}
+
+// At returns the transform at the time (from 0 to 1).
+func (m *Motion) At(t float64) *Matrix {
+	if t >= 1 {
+		return m.end
+	}
+	res := &Identity
+	for _, part := range m.parts {
+		if part.fixed != nil {
+			res = res.Multiply(part.fixed)
+		} else {
+			res = res.Multiply(interpolate(part.start, part.end, max(t, 0)))
+		}
+	}
+
+	return res
+}
+
+// End returns the transform at the end of the motion.
+func (m *Motion) End() *Matrix {
+	return m.end
+}
+
+// returns the product of the transforms.
+func product(transforms []*Matrix) *Matrix {
+	res := &Identity
+	for _, m := range transforms {
+		res = res.Multiply(m)
+	}
+
+	return res
+}
+
+// the parts of an affine transform, which is the translation of the rotation of the stretch.
+type decomposition struct {
+	translation [3]float64
+	// a unit quaternion (w, x, y, z)
+	rotation [4]float64
+	// a symmetric matrix, which scales along any three axes at right angles (and mirrors the
+	// space if the transform does)
+	stretch [3][3]float64
+}
+
+// returns the transform that is the fraction t of the way from one transform's parts to another's.
+func interpolate(start, end decomposition, t float64) *Matrix {
+	var translation [3]float64
+	var stretch [3][3]float64
+	for i := range 3 {
+		translation[i] = start.translation[i] + (end.translation[i]-start.translation[i])*t
+		for j := range 3 {
+			stretch[i][j] = start.stretch[i][j] + (end.stretch[i][j]-start.stretch[i][j])*t
+		}
+	}
+	rotation := rotationMatrix(slerp(start.rotation, end.rotation, t))
+
+	data := newData(four)
+	for i := range 3 {
+		for j := range 3 {
+			for k := range 3 {
+				data[i][j] += rotation[i][k] * stretch[k][j]
+			}
+		}
+		data[i][3] = translation[i]
+	}
+	data[3][3] = 1
+
+	return NewMatrix(data)
+}
+
+// splits the transform into its parts. The rotation is the one closest to the transform, which is
+// found by averaging the upper 3x3 matrix with its inverse transpose until it stops changing.
+func (m *Matrix) decompose() decomposition {
+	var d decomposition
+	var linear, rotation [3][3]float64
+	for i := range 3 {
+		d.translation[i] = m.data[i][3]
+		for j := range 3 {
+			linear[i][j] = m.data[i][j]
+		}
+	}
+
+	if math.Abs(determinant3(linear)) < Epsilon {
+		// a flattened transform has no closest rotation, so it's all stretch
+		d.stretch = linear
+		d.rotation = [4]float64{1, 0, 0, 0}
+
+		return d
+	}
+
+	rotation = linear
+	for range maxPolarIterations {
+		inverse := inverse3(rotation)
+		change := 0.0
+		for i := range 3 {
+			for j := range 3 {
+				next := (rotation[i][j] + inverse[j][i]) / 2
+				change = max(change, math.Abs(next-rotation[i][j]))
+				rotation[i][j] = next
+			}
+		}
+		if change < Epsilon*Epsilon {
+			break
+		}
+	}
+	if determinant3(rotation) < 0 {
+		// a rotation can't mirror the space, so the stretch does
+		for i := range 3 {
+			for j := range 3 {
+				rotation[i][j] = -rotation[i][j]
+			}
+		}
+	}
+
+	// the stretch is the rotation undone from the upper 3x3 matrix
+	for i := range 3 {
+		for j := range 3 {
+			for k := range 3 {
+				d.stretch[i][j] += rotation[k][i] * linear[k][j]
+			}
+		}
+	}
+	d.rotation = quaternion(rotation)
+
+	return d
+}
+
+// returns the determinant of a 3x3 matrix.
+func determinant3(m [3][3]float64) float64 {
+	return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+}
+
+// returns the inverse of a 3x3 matrix.
+func inverse3(m [3][3]float64) [3][3]float64 {
+	invdet := 1 / determinant3(m)
+
+	return [3][3]float64{
+		{
+			(m[1][1]*m[2][2] - m[1][2]*m[2][1]) * invdet,
+			(m[0][2]*m[2][1] - m[0][1]*m[2][2]) * invdet,
+			(m[0][1]*m[1][2] - m[0][2]*m[1][1]) * invdet,
+		},
+		{
+			(m[1][2]*m[2][0] - m[1][0]*m[2][2]) * invdet,
+			(m[0][0]*m[2][2] - m[0][2]*m[2][0]) * invdet,
+			(m[0][2]*m[1][0] - m[0][0]*m[1][2]) * invdet,
+		},
+		{
+			(m[1][0]*m[2][1] - m[1][1]*m[2][0]) * invdet,
+			(m[0][1]*m[2][0] - m[0][0]*m[2][1]) * invdet,
+			(m[0][0]*m[1][1] - m[0][1]*m[1][0]) * invdet,
+		},
+	}
+}
+
+// returns the unit quaternion (w, x, y, z) of a rotation matrix.
+func quaternion(r [3][3]float64) [4]float64 {
+	// found from the largest of the quaternion's parts, which is the most accurate
+	trace := r[0][0] + r[1][1] + r[2][2]
+	var q [4]float64
+	switch {
+	case trace > 0:
+		s := 2 * math.Sqrt(trace+1)
+		q = [4]float64{s / 4, (r[2][1] - r[1][2]) / s, (r[0][2] - r[2][0]) / s, (r[1][0] - r[0][1]) / s}
+	case r[0][0] > r[1][1] && r[0][0] > r[2][2]:
+		s := 2 * math.Sqrt(1+r[0][0]-r[1][1]-r[2][2])
+		q = [4]float64{(r[2][1] - r[1][2]) / s, s / 4, (r[0][1] + r[1][0]) / s, (r[0][2] + r[2][0]) / s}
+	case r[1][1] > r[2][2]:
+		s := 2 * math.Sqrt(1+r[1][1]-r[0][0]-r[2][2])
+		q = [4]float64{(r[0][2] - r[2][0]) / s, (r[0][1] + r[1][0]) / s, s / 4, (r[1][2] + r[2][1]) / s}
+	default:
+		s := 2 * math.Sqrt(1+r[2][2]-r[0][0]-r[1][1])
+		q = [4]float64{(r[1][0] - r[0][1]) / s, (r[0][2] + r[2][0]) / s, (r[1][2] + r[2][1]) / s, s / 4}
+	}
+
+	return q
+}
+
+// returns the rotation matrix of a unit quaternion (w, x, y, z).
+func rotationMatrix(q [4]float64) [3][3]float64 {
+	w, x, y, z := q[0], q[1], q[2], q[3]
+
+	return [3][3]float64{
+		{1 - 2*(y*y+z*z), 2 * (x*y - w*z), 2 * (x*z + w*y)},
+		{2 * (x*y + w*z), 1 - 2*(x*x+z*z), 2 * (y*z - w*x)},
+		{2 * (x*z - w*y), 2 * (y*z + w*x), 1 - 2*(x*x+y*y)},
+	}
+}
+
+// returns the rotation that is the fraction t of the way from q to q2 (unit quaternions), turning
+// at a steady rate the shorter way round.
+func slerp(q, q2 [4]float64, t float64) [4]float64 {
+	dot := q[0]*q2[0] + q[1]*q2[1] + q[2]*q2[2] + q[3]*q2[3]
+	if dot < 0 {
+		// the same rotation, the shorter way round
+		for i := range q2 {
+			q2[i] = -q2[i]
+		}
+		dot = -dot
+	}
+
+	// nearly the same rotation, which is interpolated linearly to avoid dividing by almost zero
+	a, b := 1-t, t
+	if dot < 1-Epsilon {
+		theta := math.Acos(dot)
+		a = math.Sin((1-t)*theta) / math.Sin(theta)
+		b = math.Sin(t*theta) / math.Sin(theta)
+	}
+	var res [4]float64
+	var length float64
+	for i := range res {
+		res[i] = a*q[i] + b*q2[i]
+		length += res[i] * res[i]
+	}
+	length = math.Sqrt(length)
+	for i := range res {
+		res[i] /= length
+	}
+
+	return res
+}
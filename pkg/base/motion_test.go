@@ -0,0 +1,33 @@
+package base
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestMotion(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// each transform moves to its end transform, so an orbit follows an arc
+	m := NewMotion(
+		[]*Matrix{RotateY(0), Translate(2, 0, 0)},
+		[]*Matrix{RotateY(2 * math.Pi / 3), Translate(2, 0, 0)},
+	)
+	g.Expect(m.End().Equals(RotateY(2 * math.Pi / 3).Multiply(Translate(2, 0, 0)))).To(BeTrue())
+	g.Expect(m.At(0).Equals(Translate(2, 0, 0))).To(BeTrue())
+	g.Expect(m.At(1)).To(Equal(m.End()))
+	g.Expect(m.At(0.5).Equals(RotateY(math.Pi / 3).Multiply(Translate(2, 0, 0)))).To(BeTrue())
+	center := m.At(0.25).MultiplyTuple(Origin)
+	g.Expect(center.Subtract(Origin).Magnitude()).To(BeNumerically("~", 2, Epsilon))
+
+	// without matching transforms, the whole transform is interpolated
+	m = NewMotion([]*Matrix{Translate(2, 0, 0)}, []*Matrix{RotateY(math.Pi / 2), Translate(2, 0, 0)})
+	g.Expect(m.At(0.5).Equals(Translate(1, 0, -1).Multiply(RotateY(math.Pi / 4)))).To(BeTrue())
+
+	// a singular end transform still gives a transform
+	m = NewMotion([]*Matrix{Scale(1, 1, 1)}, []*Matrix{Scale(0, 1, 1)})
+	g.Expect(m.At(0.5).Equals(Scale(0.5, 1, 1))).To(BeTrue())
+}
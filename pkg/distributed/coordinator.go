@@ -0,0 +1,189 @@
+package distributed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene"
+)
+
+// Options control how a render is split between workers.
+type Options struct {
+	// The tile size, order, crop, and progress callback are used as in a local render, and
+	// Workers is the number of tiles each worker renders at once.
+	scene.RenderOptions
+	// Timeout is the longest a worker may take to respond to a request before it's treated as
+	// dead (zero means no limit).
+	Timeout time.Duration
+}
+
+// Render renders a scene on the workers at the given addresses (host:port), and assembles the
+// tiles into a canvas of the given size. The scene description is sent to each worker as is.
+// A worker that fails to render a tile is no longer used, and its tiles are given to the others.
+// If the context is cancelled, or every worker fails, the finished part of the canvas is returned
+// with an error.
+func Render(
+	ctx context.Context,
+	description []byte,
+	width, height int,
+	addresses []string,
+	opts Options,
+) (*image.Canvas, error) {
+	if opts.Workers < 1 {
+		opts.Workers = runtime.NumCPU()
+	}
+	client := &http.Client{Timeout: opts.Timeout}
+	canvas := image.NewCanvas(width, height)
+	tiles := opts.Tiles(width, height)
+	if len(tiles) == 0 {
+		return canvas, nil
+	}
+
+	// a failed tile is put back in the queue, which always has room for every tile
+	queue := make(chan scene.Crop, len(tiles))
+	for _, t := range tiles {
+		queue <- t
+	}
+	remaining := int64(len(tiles))
+	tileDone := scene.TileCounter(len(tiles), opts.Progress)
+
+	renderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var errsMu sync.Mutex
+	var errs []error
+	addErr := func(err error) {
+		errsMu.Lock()
+		errs = append(errs, err)
+		errsMu.Unlock()
+	}
+	for _, address := range addresses {
+		if err := sendScene(renderCtx, client, address, description); err != nil {
+			addErr(err)
+			continue
+		}
+
+		// only the first error of a worker is kept, since the rest are from stopping it
+		workerCtx, stopWorker := context.WithCancel(renderCtx)
+		var failOnce sync.Once
+		fail := func(err error) {
+			failOnce.Do(func() { addErr(err) })
+			stopWorker()
+		}
+
+		for range opts.Workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-workerCtx.Done():
+						return
+					case t := <-queue:
+						if err := renderTile(workerCtx, client, address, t, canvas); err != nil {
+							queue <- t
+							fail(err)
+
+							return
+						}
+						tileDone()
+						if atomic.AddInt64(&remaining, -1) == 0 {
+							cancel()
+						}
+					}
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	switch {
+	case atomic.LoadInt64(&remaining) == 0:
+		return canvas, nil
+	case ctx.Err() != nil:
+		return canvas, ctx.Err()
+	default:
+		return canvas, fmt.Errorf("every worker failed with %d of %d tiles left to render: %w",
+			remaining, len(tiles), errors.Join(errs...))
+	}
+}
+
+// sends the scene description to a worker.
+func sendScene(ctx context.Context, client *http.Client, address string, description []byte) error {
+	if _, err := post(ctx, client, address, ScenePath, description); err != nil {
+		return fmt.Errorf("error sending scene to worker %s: %w", address, err)
+	}
+
+	return nil
+}
+
+// renders a tile on a worker, and writes its pixels to the canvas.
+func renderTile(ctx context.Context, client *http.Client, address string, t scene.Crop, canvas *image.Canvas) error {
+	body, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("error encoding tile: %w", err)
+	}
+	resp, err := post(ctx, client, address, TilePath, body)
+	if err != nil {
+		return fmt.Errorf("error rendering tile on worker %s: %w", address, err)
+	}
+
+	var result tileResult
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return fmt.Errorf("error decoding tile from worker %s: %w", address, err)
+	}
+	width, height := t.X1-t.X0, t.Y1-t.Y0
+	if result.Width != width || result.Height != height || len(result.Pixels) != 3*width*height {
+		return fmt.Errorf("worker %s returned a %dx%d tile with %d values, instead of %dx%d",
+			address, result.Width, result.Height, len(result.Pixels), width, height)
+	}
+
+	for y := range height {
+		for x := range width {
+			i := 3 * (y*width + x)
+			canvas.WritePixel(t.X0+x, t.Y0+y, image.NewColor(result.Pixels[i], result.Pixels[i+1], result.Pixels[i+2]))
+		}
+	}
+
+	return nil
+}
+
+// posts a request to a worker, and returns the response body if it was successful.
+func post(ctx context.Context, client *http.Client, address, path string, body []byte) ([]byte, error) {
+	url := address + path
+	if !strings.Contains(address, "://") {
+		url = "http://" + url
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	return respBody, nil
+}
@@ -0,0 +1,160 @@
+package distributed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene"
+)
+
+// starts a test server for a new worker, which fails every tile after the given number of tiles
+// (or never, if it's negative).
+func startWorker(t *testing.T, tilesBeforeFailing int) (string, *atomic.Int64) {
+	t.Helper()
+
+	worker := NewWorker(testLoader)
+	var tiles atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == TilePath {
+			if n := tiles.Add(1); tilesBeforeFailing >= 0 && n > int64(tilesBeforeFailing) {
+				http.Error(rw, "worker failed", http.StatusInternalServerError)
+				return
+			}
+		}
+		worker.ServeHTTP(rw, req)
+	}))
+	t.Cleanup(server.Close)
+
+	return strings.TrimPrefix(server.URL, "http://"), &tiles
+}
+
+// returns the address of a server that has been shut down.
+func deadAddress() string {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	return server.URL
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		failing []int
+		dead    int
+	}{
+		{
+			name: "one worker",
+		},
+		{
+			name:    "several workers",
+			failing: []int{-1, -1},
+		},
+		{
+			name:    "failed worker",
+			failing: []int{-1, 2},
+		},
+		{
+			name: "dead worker",
+			dead: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			address, tiles := startWorker(t, -1)
+			addresses := []string{address}
+			for _, n := range test.failing {
+				addr, _ := startWorker(t, n)
+				addresses = append(addresses, addr)
+			}
+			for range test.dead {
+				addresses = append(addresses, deadAddress())
+			}
+
+			var mu sync.Mutex
+			var reports []scene.Progress
+			opts := Options{RenderOptions: scene.RenderOptions{
+				Workers:  2,
+				TileSize: 4,
+				Progress: func(p scene.Progress) {
+					mu.Lock()
+					reports = append(reports, p)
+					mu.Unlock()
+				},
+			}}
+			canvas, err := Render(context.Background(), []byte(testDescription), 20, 15, addresses, opts)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(canvas).To(Equal(testImage()))
+			g.Expect(canvas.PixelAt(10, 7)).ToNot(Equal(image.Black))
+			g.Expect(tiles.Load()).To(BeNumerically(">", 0))
+
+			// each of the 20 tiles is only counted once
+			g.Expect(reports).To(HaveLen(20))
+			g.Expect(reports[19].TilesDone).To(Equal(20))
+		})
+	}
+}
+
+func TestRender_Crop(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	address, tiles := startWorker(t, -1)
+	opts := Options{RenderOptions: scene.RenderOptions{TileSize: 4, Crop: scene.Crop{X0: 5, Y0: 5, X1: 13, Y1: 9}}}
+	canvas, err := Render(context.Background(), []byte(testDescription), 20, 15, []string{address}, opts)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(tiles.Load()).To(Equal(int64(2)))
+	g.Expect(canvas.Crop(5, 5, 13, 9)).To(Equal(testImage().Crop(5, 5, 13, 9)))
+	g.Expect(canvas.PixelAt(10, 10)).To(Equal(image.Black))
+}
+
+func TestRender_Errors(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// no worker accepts the scene
+	address, _ := startWorker(t, -1)
+	_, err := Render(context.Background(), []byte("unknown"), 20, 15, []string{address, deadAddress()}, Options{})
+	g.Expect(err).To(MatchError(ContainSubstring("unknown scene")))
+	g.Expect(err).To(MatchError(ContainSubstring("connection refused")))
+
+	// every worker fails part way through, and the finished tiles are kept
+	failing, _ := startWorker(t, 3)
+	opts := Options{RenderOptions: scene.RenderOptions{Workers: 1, TileSize: 4}}
+	canvas, err := Render(context.Background(), []byte(testDescription), 20, 15, []string{failing}, opts)
+	g.Expect(err).To(MatchError(ContainSubstring("17 of 20 tiles left")))
+	g.Expect(err).To(MatchError(ContainSubstring("worker failed")))
+	g.Expect(canvas.Crop(0, 0, 12, 4)).To(Equal(testImage().Crop(0, 0, 12, 4)))
+
+	// a worker that takes too long is treated as dead
+	slow := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == TilePath {
+			time.Sleep(time.Second)
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer slow.Close()
+	opts.Timeout = 50 * time.Millisecond
+	_, err = Render(context.Background(), []byte(testDescription), 20, 15, []string{slow.URL}, opts)
+	g.Expect(err).To(MatchError(ContainSubstring("Client.Timeout")))
+
+	// a cancelled render returns the context's error
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Render(ctx, []byte(testDescription), 20, 15, []string{address}, Options{})
+	g.Expect(err).To(MatchError(context.Canceled))
+}
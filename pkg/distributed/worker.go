@@ -0,0 +1,120 @@
+package distributed
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene"
+)
+
+const (
+	// ScenePath is the path that a worker receives the scene on.
+	ScenePath = "/scene"
+	// TilePath is the path that a worker renders tiles on.
+	TilePath = "/tile"
+)
+
+// SceneLoader builds the camera and world of a scene from its description, which is sent by the
+// coordinator as is.
+type SceneLoader func(description []byte) (*scene.Camera, *scene.World, error)
+
+// tileResult holds the rendered pixels of a tile, as sent from a worker to the coordinator.
+type tileResult struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// the red, green, and blue values of each pixel, row by row
+	Pixels []float64 `json:"pixels"`
+}
+
+// Worker is an HTTP handler that renders tiles of a scene for a coordinator. The scene is sent
+// to ScenePath, which replaces any previous scene, and then each tile (a scene.Crop) is sent to
+// TilePath, which responds with the tile's pixels.
+type Worker struct {
+	load   SceneLoader
+	mux    *http.ServeMux
+	mu     sync.RWMutex
+	camera *scene.Camera
+	world  *scene.World
+}
+
+// NewWorker returns a new Worker object, which loads scenes with the given loader.
+func NewWorker(load SceneLoader) *Worker {
+	w := &Worker{load: load, mux: http.NewServeMux()}
+	w.mux.HandleFunc("POST "+ScenePath, w.handleScene)
+	w.mux.HandleFunc("POST "+TilePath, w.handleTile)
+
+	return w
+}
+
+// ServeHTTP handles a request from the coordinator.
+func (w *Worker) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	w.mux.ServeHTTP(rw, req)
+}
+
+// loads the scene in the request body.
+func (w *Worker) handleScene(rw http.ResponseWriter, req *http.Request) {
+	description, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("error reading scene: %v", err), http.StatusBadRequest)
+		return
+	}
+	camera, world, err := w.load(description)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("error loading scene: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	w.mu.Lock()
+	w.camera, w.world = camera, world
+	w.mu.Unlock()
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// renders the tile in the request body, and responds with its pixels.
+func (w *Worker) handleTile(rw http.ResponseWriter, req *http.Request) {
+	var crop scene.Crop
+	if err := json.NewDecoder(req.Body).Decode(&crop); err != nil {
+		http.Error(rw, fmt.Sprintf("error decoding tile: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	w.mu.RLock()
+	camera, world := w.camera, w.world
+	w.mu.RUnlock()
+	if camera == nil {
+		http.Error(rw, "no scene has been loaded", http.StatusConflict)
+		return
+	}
+
+	// the coordinator decides how many tiles are rendered at once, so each one uses a single goroutine
+	canvas, err := scene.RenderCrop(req.Context(), camera, world, scene.RenderOptions{Workers: 1, Crop: crop})
+	if err != nil {
+		// the coordinator has gone away
+		http.Error(rw, fmt.Sprintf("error rendering tile: %v", err), http.StatusServiceUnavailable)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(newTileResult(canvas)); err != nil {
+		http.Error(rw, fmt.Sprintf("error encoding tile: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// returns the pixels of a canvas as a tile result.
+func newTileResult(canvas *image.Canvas) tileResult {
+	width, height := canvas.Size()
+	pixels := make([]float64, 0, 3*width*height)
+	for y := range height {
+		for x := range width {
+			r, g, b := canvas.PixelAt(x, y).RGB()
+			pixels = append(pixels, r, g, b)
+		}
+	}
+
+	return tileResult{Width: width, Height: height, Pixels: pixels}
+}
@@ -0,0 +1,108 @@
+package distributed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/object"
+)
+
+const testDescription = "test scene"
+
+// loads a sphere in front of the camera, if the description is the test scene.
+func testLoader(description []byte) (*scene.Camera, *scene.World, error) {
+	if string(description) != testDescription {
+		return nil, nil, errors.New("unknown scene")
+	}
+
+	sphere := object.NewSphere()
+	sphere.GetMaterial().Color = image.NewColor(0.8, 1.0, 0.6)
+	light := scene.NewPointLight(base.NewPoint(-10, 10, -10), image.White)
+	camera := scene.NewCamera(20, 15, math.Pi/4)
+	camera.SetTransform(base.ViewTransform(base.NewPoint(0, 0, -5), base.Origin, base.NewVector(0, 1, 0)))
+
+	return camera, scene.NewWorld([]scene.Light{light}, []object.Object{sphere}), nil
+}
+
+// returns the image of the test scene rendered locally.
+func testImage() *image.Canvas {
+	camera, world, _ := testLoader([]byte(testDescription))
+
+	return scene.Render(camera, world)
+}
+
+// posts a request body to a worker, and returns the response.
+func postToWorker(w *Worker, path string, body []byte) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	w.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestWorker(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	w := NewWorker(testLoader)
+	tile, err := json.Marshal(scene.Crop{X0: 4, Y0: 2, X1: 12, Y1: 10})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// a tile can't be rendered before the scene is loaded
+	rec := postToWorker(w, TilePath, tile)
+	g.Expect(rec.Code).To(Equal(http.StatusConflict))
+
+	rec = postToWorker(w, ScenePath, []byte("unknown"))
+	g.Expect(rec.Code).To(Equal(http.StatusBadRequest))
+	g.Expect(rec.Body.String()).To(ContainSubstring("unknown scene"))
+
+	rec = postToWorker(w, ScenePath, []byte(testDescription))
+	g.Expect(rec.Code).To(Equal(http.StatusNoContent))
+
+	// the tile's pixels match the same part of the full image
+	rec = postToWorker(w, TilePath, tile)
+	g.Expect(rec.Code).To(Equal(http.StatusOK))
+	var result tileResult
+	g.Expect(json.Unmarshal(rec.Body.Bytes(), &result)).To(Succeed())
+	g.Expect(result).To(Equal(newTileResult(testImage().Crop(4, 2, 12, 10))))
+	g.Expect(result.Pixels).To(HaveLen(3 * 8 * 8))
+
+	rec = postToWorker(w, TilePath, []byte("not a tile"))
+	g.Expect(rec.Code).To(Equal(http.StatusBadRequest))
+
+	rec = httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, TilePath, nil))
+	g.Expect(rec.Code).To(Equal(http.StatusMethodNotAllowed))
+
+	// a tile isn't rendered for a coordinator that has gone away
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec = httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequestWithContext(ctx, http.MethodPost, TilePath, bytes.NewReader(tile)))
+	g.Expect(rec.Code).To(Equal(http.StatusServiceUnavailable))
+}
+
+func TestNewTileResult(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	c := image.NewCanvas(2, 2)
+	c.WritePixel(1, 0, image.NewColor(0.1, 0.2, 0.3))
+	c.WritePixel(0, 1, image.NewColor(0.4, 0.5, 0.6))
+	g.Expect(newTileResult(c)).To(Equal(tileResult{
+		Width:  2,
+		Height: 2,
+		Pixels: []float64{0, 0, 0, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0, 0, 0},
+	}))
+}
@@ -0,0 +1,93 @@
+package image
+
+import "sync"
+
+// Accumulator keeps a running average of the color samples of each pixel, so that samples can be
+// added to an image without rendering it again. It is safe for concurrent use.
+type Accumulator struct {
+	mu     sync.Mutex
+	width  int
+	height int
+	sums   [][]Color
+	counts [][]int
+}
+
+// NewAccumulator returns a new Accumulator object with no samples.
+func NewAccumulator(width, height int) *Accumulator {
+	sums := make([][]Color, width)
+	counts := make([][]int, width)
+	for i := range sums {
+		sums[i] = make([]Color, height)
+		counts[i] = make([]int, height)
+	}
+
+	return &Accumulator{
+		width:  width,
+		height: height,
+		sums:   sums,
+		counts: counts,
+	}
+}
+
+// AddSample adds a color sample to a pixel.
+func (a *Accumulator) AddSample(x, y int, color *Color) {
+	if x >= a.width || y >= a.height {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.sums[x][y] = *a.sums[x][y].Add(color)
+	a.counts[x][y]++
+}
+
+// AddSamples adds a color sample to each pixel of a region of the image, from the pixels of a canvas
+// whose top left corner is at (x, y). The samples are added under a single lock, so goroutines that
+// each add a region at once don't wait on each other for every pixel.
+func (a *Accumulator) AddSamples(x, y int, samples *Canvas) {
+	width, height := samples.Size()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i := range width {
+		for j := range height {
+			if x+i >= a.width || y+j >= a.height {
+				continue
+			}
+			a.sums[x+i][y+j] = *a.sums[x+i][y+j].Add(&samples.pixels[i][j])
+			a.counts[x+i][y+j]++
+		}
+	}
+}
+
+// SamplesAt returns the number of samples added to a pixel.
+func (a *Accumulator) SamplesAt(x, y int) int {
+	if x >= a.width || y >= a.height {
+		return 0
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.counts[x][y]
+}
+
+// Canvas returns a new canvas with the average of each pixel's samples. Pixels without any
+// samples are black.
+func (a *Accumulator) Canvas() *Canvas {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	c := NewCanvas(a.width, a.height)
+	for x := range a.width {
+		for y := range a.height {
+			if count := a.counts[x][y]; count > 0 {
+				c.pixels[x][y] = *a.sums[x][y].Multiply(1 / float64(count))
+			}
+		}
+	}
+
+	return c
+}
@@ -0,0 +1,78 @@
+package image
+
+import (
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestNewAccumulator(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	a := NewAccumulator(4, 3)
+	g.Expect(a.SamplesAt(2, 1)).To(BeZero())
+	g.Expect(a.Canvas()).To(Equal(NewCanvas(4, 3)))
+}
+
+func TestAccumulatorAddSample(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	a := NewAccumulator(4, 3)
+	a.AddSample(1, 2, NewColor(1, 0, 0.5))
+	a.AddSample(1, 2, NewColor(0, 0, 0.5))
+	a.AddSample(3, 0, White)
+	// outside the image
+	a.AddSample(4, 3, White)
+
+	g.Expect(a.SamplesAt(1, 2)).To(Equal(2))
+	g.Expect(a.SamplesAt(3, 0)).To(Equal(1))
+	g.Expect(a.SamplesAt(0, 0)).To(BeZero())
+	g.Expect(a.SamplesAt(4, 3)).To(BeZero())
+
+	c := a.Canvas()
+	g.Expect(c.PixelAt(1, 2)).To(Equal(NewColor(0.5, 0, 0.5)))
+	g.Expect(c.PixelAt(3, 0)).To(Equal(White))
+	g.Expect(c.PixelAt(0, 0)).To(Equal(Black))
+
+	// the canvas is a copy, which doesn't change with new samples
+	a.AddSample(3, 0, Black)
+	g.Expect(c.PixelAt(3, 0)).To(Equal(White))
+	g.Expect(a.Canvas().PixelAt(3, 0)).To(Equal(NewColor(0.5, 0.5, 0.5)))
+
+	// samples can be added concurrently
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.AddSample(0, 1, White)
+			_ = a.Canvas()
+		}()
+	}
+	wg.Wait()
+	g.Expect(a.SamplesAt(0, 1)).To(Equal(10))
+}
+
+func TestAccumulatorAddSamples(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	a := NewAccumulator(4, 3)
+	a.AddSample(2, 1, White)
+	samples := NewCanvas(3, 2)
+	samples.WritePixel(0, 0, NewColor(1, 0, 0))
+	samples.WritePixel(2, 1, NewColor(0, 0, 1))
+	// the region hangs off the right edge of the image
+	a.AddSamples(2, 1, samples)
+
+	g.Expect(a.SamplesAt(2, 1)).To(Equal(2))
+	g.Expect(a.SamplesAt(3, 2)).To(Equal(1))
+	g.Expect(a.SamplesAt(1, 1)).To(BeZero())
+	c := a.Canvas()
+	g.Expect(c.PixelAt(2, 1)).To(Equal(NewColor(1, 0.5, 0.5)))
+	g.Expect(c.PixelAt(3, 2)).To(Equal(Black))
+	g.Expect(c.PixelAt(2, 2)).To(Equal(Black))
+}
@@ -1,10 +1,16 @@
 package image
 
 import (
+	"bufio"
 	"fmt"
+	goimage "image"
+	_ "image/jpeg" // register JPEG decoding
+	_ "image/png"  // register PNG decoding
+	"io"
 	"math"
 	"os"
 	"strings"
+	"unicode"
 )
 
 // Canvas represents a grid of pixels for displaying an image.
@@ -29,6 +35,11 @@ func NewCanvas(width, height int) *Canvas {
 	}
 }
 
+// Size returns the width and height of a Canvas.
+func (c *Canvas) Size() (int, int) {
+	return c.width, c.height
+}
+
 // WritePixel sets a Canvas's pixel to a color.
 func (c *Canvas) WritePixel(x, y int, color *Color) {
 	if (x <= c.width-1) && (y <= c.height-1) {
@@ -45,6 +56,18 @@ func (c *Canvas) PixelAt(x, y int) *Color {
 	return Black
 }
 
+// Crop returns a new canvas with the pixels from (x0, y0) up to, but not including, (x1, y1).
+func (c *Canvas) Crop(x0, y0, x1, y1 int) *Canvas {
+	cropped := NewCanvas(x1-x0, y1-y0)
+	for x := range cropped.width {
+		for y := range cropped.height {
+			cropped.pixels[x][y] = *c.PixelAt(x0+x, y0+y)
+		}
+	}
+
+	return cropped
+}
+
 // returns a PPM (portable pixelmap) string of the canvas.
 func (c *Canvas) toPPM() string {
 	header := fmt.Sprintf("P3\n%d %d\n%d\n", c.width, c.height, 255)
@@ -85,6 +108,123 @@ func (c *Canvas) WriteToFile(name string) error {
 	return nil
 }
 
+// ReadFile reads an image file (PPM, PNG, or JPEG) into a new canvas.
+func ReadFile(name string) (*Canvas, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	magic, err := reader.Peek(2)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+	if string(magic) == "P3" || string(magic) == "P6" {
+		return readPPM(reader)
+	}
+
+	img, _, err := goimage.Decode(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding image: %w", err)
+	}
+	bounds := img.Bounds()
+	c := NewCanvas(bounds.Dx(), bounds.Dy())
+	for y := range c.height {
+		for x := range c.width {
+			// colors are 16 bit
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			c.pixels[x][y] = *NewColor(float64(r)/0xffff, float64(g)/0xffff, float64(b)/0xffff)
+		}
+	}
+
+	return c, nil
+}
+
+// reads a plain (P3) or raw (P6) PPM image into a new canvas.
+func readPPM(reader *bufio.Reader) (*Canvas, error) {
+	var magic string
+	var width, height, maxVal int
+	for _, field := range []any{&magic, &width, &height, &maxVal} {
+		if err := scanPPMField(reader, field); err != nil {
+			return nil, fmt.Errorf("error reading PPM header: %w", err)
+		}
+	}
+	if width < 1 || height < 1 || maxVal < 1 || maxVal > 0xffff {
+		return nil, fmt.Errorf("invalid PPM header: %dx%d with max value %d", width, height, maxVal)
+	}
+
+	c := NewCanvas(width, height)
+	if magic == "P6" {
+		// a single whitespace character separates the header from the binary data
+		if _, err := reader.ReadByte(); err != nil {
+			return nil, fmt.Errorf("error reading PPM data: %w", err)
+		}
+	}
+	for y := range height {
+		for x := range width {
+			var rgb [3]int
+			for i := range rgb {
+				var err error
+				if magic == "P6" {
+					rgb[i], err = readPPMByte(reader, maxVal)
+				} else {
+					err = scanPPMField(reader, &rgb[i])
+				}
+				if err != nil {
+					return nil, fmt.Errorf("error reading PPM data: %w", err)
+				}
+			}
+			scale := float64(maxVal)
+			c.pixels[x][y] = *NewColor(float64(rgb[0])/scale, float64(rgb[1])/scale, float64(rgb[2])/scale)
+		}
+	}
+
+	return c, nil
+}
+
+// scans the next whitespace separated field of a PPM file, skipping comments.
+func scanPPMField(reader *bufio.Reader, field any) error {
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return fmt.Errorf("unexpected end of file: %w", err)
+		}
+		if b == '#' {
+			if _, err := reader.ReadString('\n'); err != nil {
+				return fmt.Errorf("unexpected end of file: %w", err)
+			}
+		} else if !unicode.IsSpace(rune(b)) {
+			if err := reader.UnreadByte(); err != nil {
+				return fmt.Errorf("error reading field: %w", err)
+			}
+
+			break
+		}
+	}
+	if _, err := fmt.Fscan(reader, field); err != nil {
+		return fmt.Errorf("error scanning field: %w", err)
+	}
+
+	return nil
+}
+
+// reads a binary value from a raw PPM file, which uses 2 bytes if the max value is over 255.
+func readPPMByte(reader *bufio.Reader, maxVal int) (int, error) {
+	if maxVal < 256 {
+		b, err := reader.ReadByte()
+
+		return int(b), err
+	}
+	var buf [2]byte
+	if _, err := io.ReadFull(reader, buf[:]); err != nil {
+		return 0, err
+	}
+
+	return int(buf[0])<<8 | int(buf[1]), nil
+}
+
 // scales a color's values to be from 0 to 255.
 func scalePixel(color *Color) (int64, int64, int64) {
 	return scaleColor(color.red), scaleColor(color.green), scaleColor(color.blue)
@@ -1,6 +1,11 @@
 package image
 
 import (
+	goimage "image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -13,6 +18,9 @@ func TestNewCanvas(t *testing.T) {
 	c := NewCanvas(10, 20)
 	g.Expect(c.width).To(Equal(10))
 	g.Expect(c.height).To(Equal(20))
+	width, height := c.Size()
+	g.Expect(width).To(Equal(10))
+	g.Expect(height).To(Equal(20))
 	g.Expect(len(c.pixels)).To(Equal(10))
 	for _, column := range c.pixels {
 		g.Expect(len(column)).To(Equal(20))
@@ -34,6 +42,23 @@ func TestWritePixel(t *testing.T) {
 	g.Expect(c.PixelAt(20, 20)).To(Equal(Black))
 }
 
+func TestCrop(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	c := NewCanvas(10, 8)
+	c.WritePixel(2, 3, White)
+	c.WritePixel(5, 6, NewColor(1, 0, 0))
+	c.WritePixel(6, 6, NewColor(0, 1, 0))
+
+	cropped := c.Crop(2, 3, 6, 7)
+	g.Expect(cropped.width).To(Equal(4))
+	g.Expect(cropped.height).To(Equal(4))
+	g.Expect(cropped.PixelAt(0, 0)).To(Equal(White))
+	g.Expect(cropped.PixelAt(3, 3)).To(Equal(NewColor(1, 0, 0)))
+	g.Expect(cropped.PixelAt(1, 1)).To(Equal(Black))
+}
+
 func TestToPPM(t *testing.T) {
 	t.Parallel()
 	g := NewWithT(t)
@@ -70,3 +95,62 @@ func TestToPPM(t *testing.T) {
 `
 	g.Expect(c.toPPM()).To(Equal(expPPM))
 }
+
+func TestReadFile(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+
+	// round trip through a plain PPM file
+	c := NewCanvas(3, 2)
+	c.WritePixel(0, 0, White)
+	c.WritePixel(2, 1, NewColor(1, 0, 0))
+	c.WritePixel(1, 1, NewColor(0, 1, 0))
+	plain := filepath.Join(dir, "plain.ppm")
+	g.Expect(c.WriteToFile(plain)).To(Succeed())
+
+	read, err := ReadFile(plain)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(read.width).To(Equal(3))
+	g.Expect(read.height).To(Equal(2))
+	g.Expect(read.PixelAt(0, 0)).To(Equal(White))
+	g.Expect(read.PixelAt(2, 1)).To(Equal(NewColor(1, 0, 0)))
+	g.Expect(read.PixelAt(1, 1)).To(Equal(NewColor(0, 1, 0)))
+	g.Expect(read.PixelAt(1, 0)).To(Equal(Black))
+
+	// raw PPM file with a comment
+	raw := filepath.Join(dir, "raw.ppm")
+	data := append([]byte("P6\n# comment\n2 1\n255\n"), 255, 0, 0, 0, 0, 255)
+	g.Expect(os.WriteFile(raw, data, 0o600)).To(Succeed())
+
+	read, err = ReadFile(raw)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(read.PixelAt(0, 0)).To(Equal(NewColor(1, 0, 0)))
+	g.Expect(read.PixelAt(1, 0)).To(Equal(NewColor(0, 0, 1)))
+
+	// PNG file
+	img := goimage.NewRGBA(goimage.Rect(0, 0, 2, 2))
+	img.Set(1, 0, color.RGBA{R: 255, A: 255})
+	img.Set(0, 1, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	pngFile := filepath.Join(dir, "image.png")
+	f, err := os.Create(pngFile)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(png.Encode(f, img)).To(Succeed())
+	g.Expect(f.Close()).To(Succeed())
+
+	read, err = ReadFile(pngFile)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(read.PixelAt(0, 0)).To(Equal(Black))
+	g.Expect(read.PixelAt(1, 0)).To(Equal(NewColor(1, 0, 0)))
+	g.Expect(read.PixelAt(0, 1)).To(Equal(White))
+
+	// invalid files
+	_, err = ReadFile(filepath.Join(dir, "missing.ppm"))
+	g.Expect(err).To(HaveOccurred())
+
+	invalid := filepath.Join(dir, "invalid.ppm")
+	g.Expect(os.WriteFile(invalid, []byte("P3\n2 2\n255\n0 0 0\n"), 0o600)).To(Succeed())
+	_, err = ReadFile(invalid)
+	g.Expect(err).To(HaveOccurred())
+}
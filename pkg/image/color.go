@@ -1,6 +1,8 @@
 package image
 
 import (
+	"math"
+
 	"github.com/sjberman/golang-ray-tracer/pkg/base"
 )
 
@@ -32,6 +34,11 @@ func NewColor(r, g, b float64) *Color {
 	}
 }
 
+// RGB returns the red, green, and blue values of the color.
+func (c *Color) RGB() (float64, float64, float64) {
+	return c.red, c.green, c.blue
+}
+
 // Add adds two colors together and returns the result.
 func (c *Color) Add(c2 *Color) *Color {
 	return NewColor(c.red+c2.red, c.green+c2.green, c.blue+c2.blue)
@@ -52,6 +59,16 @@ func (c *Color) MultiplyColor(c2 *Color) *Color {
 	return NewColor(c.red*c2.red, c.green*c2.green, c.blue*c2.blue)
 }
 
+// Difference returns the largest difference between the red, green, or blue values of two colors.
+func (c *Color) Difference(c2 *Color) float64 {
+	return max(math.Abs(c.red-c2.red), math.Abs(c.green-c2.green), math.Abs(c.blue-c2.blue))
+}
+
+// Luminance returns the perceived brightness of the color (using the Rec. 709 weights).
+func (c *Color) Luminance() float64 {
+	return 0.2126*c.red + 0.7152*c.green + 0.0722*c.blue
+}
+
 // Equals returns whether or not two colors are equal to each other.
 func (c *Color) Equals(c2 *Color) bool {
 	if !base.EqualFloats(c.red, c2.red) {
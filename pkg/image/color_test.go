@@ -16,6 +16,16 @@ func TestNewColor(t *testing.T) {
 	g.Expect(color.blue).To(Equal(3.0))
 }
 
+func TestColorRGB(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	r, gr, b := NewColor(-0.5, 0.4, 1.7).RGB()
+	g.Expect(r).To(Equal(-0.5))
+	g.Expect(gr).To(Equal(0.4))
+	g.Expect(b).To(Equal(1.7))
+}
+
 func TestColorAdd(t *testing.T) {
 	t.Parallel()
 	g := NewWithT(t)
@@ -58,6 +68,27 @@ func TestColorMultiplyColor(t *testing.T) {
 	g.Expect(res.Equals(expColor)).To(BeTrue())
 }
 
+func TestColorDifference(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	c1 := NewColor(0.9, 0.6, 0.75)
+	c2 := NewColor(0.7, 0.1, 1.0)
+	g.Expect(c1.Difference(c2)).To(Equal(0.5))
+	g.Expect(c2.Difference(c1)).To(Equal(0.5))
+	g.Expect(c1.Difference(c1)).To(BeZero())
+}
+
+func TestColorLuminance(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	g.Expect(White.Luminance()).To(BeNumerically("~", 1))
+	g.Expect(Black.Luminance()).To(BeZero())
+	g.Expect(NewColor(0, 1, 0).Luminance()).To(Equal(0.7152))
+	g.Expect(NewColor(0, 1, 0).Luminance()).To(BeNumerically(">", NewColor(1, 0, 1).Luminance()))
+}
+
 func TestColorEquals(t *testing.T) {
 	t.Parallel()
 	g := NewWithT(t)
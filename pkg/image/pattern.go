@@ -147,6 +147,78 @@ func checkerAt(point *base.Tuple, p *PatternObject) *Color {
 	return p.color2
 }
 
+// Ways that a point on an object is mapped onto an image.
+const (
+	PlanarMapping      = "planar"      // x and z are the u and v, repeating every unit
+	SphericalMapping   = "spherical"   // longitude and latitude around the origin
+	CylindricalMapping = "cylindrical" // angle around the y axis, with y repeating every unit
+)
+
+// ImagePattern represents an image wrapped around an object.
+type ImagePattern struct {
+	*PatternObject
+	canvas  *Canvas
+	mapping string
+}
+
+// NewImagePattern returns a new ImagePattern object, which maps points onto the image
+// using the mapping.
+func NewImagePattern(canvas *Canvas, mapping string) *ImagePattern {
+	p := &ImagePattern{
+		canvas:  canvas,
+		mapping: mapping,
+	}
+	p.PatternObject = NewPattern(nil, nil, func(point *base.Tuple, _ *PatternObject) *Color {
+		return p.colorAt(p.uvAt(point))
+	})
+
+	return p
+}
+
+// uvAt returns the (u, v) location on the image from 0 to 1 for a point, where (0, 0) is the top left.
+func (p *ImagePattern) uvAt(point *base.Tuple) (float64, float64) {
+	switch p.mapping {
+	case SphericalMapping:
+		// same orientation as an equirectangular camera, with -z at the center of the image
+		x, y, z := point.GetX(), point.GetY(), point.GetZ()
+		radius := math.Sqrt(x*x + y*y + z*z)
+		if radius == 0 {
+			return 0.5, 0.5
+		}
+		longitude := math.Atan2(-x, -z)
+		latitude := math.Asin(y / radius)
+
+		return longitude/(2*math.Pi) + 0.5, 0.5 - latitude/math.Pi
+	case CylindricalMapping:
+		longitude := math.Atan2(-point.GetX(), -point.GetZ())
+
+		return longitude/(2*math.Pi) + 0.5, 1 - grtMath.Mod(point.GetY(), 1)
+	default:
+		return grtMath.Mod(point.GetX(), 1), 1 - grtMath.Mod(point.GetZ(), 1)
+	}
+}
+
+// colorAt returns the bilinearly interpolated color of the image at (u, v).
+func (p *ImagePattern) colorAt(u, v float64) *Color {
+	// pixel centers are at half coordinates
+	x := u*float64(p.canvas.width) - 0.5
+	y := v*float64(p.canvas.height) - 0.5
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := x-x0, y-y0
+
+	pixel := func(px, py float64) *Color {
+		// wrap around horizontally, and clamp vertically
+		col := int(grtMath.Mod(px, float64(p.canvas.width)))
+		row := min(max(int(py), 0), p.canvas.height-1)
+
+		return p.canvas.PixelAt(col, row)
+	}
+	top := pixel(x0, y0).Multiply(1 - fx).Add(pixel(x0+1, y0).Multiply(fx))
+	bottom := pixel(x0, y0+1).Multiply(1 - fx).Add(pixel(x0+1, y0+1).Multiply(fx))
+
+	return top.Multiply(1 - fy).Add(bottom.Multiply(fy))
+}
+
 // MockPattern is a mock pattern object for unit testing.
 type MockPattern struct {
 	*PatternObject
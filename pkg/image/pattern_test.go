@@ -269,3 +269,82 @@ func TestPatternAt_Checker(t *testing.T) {
 		})
 	}
 }
+
+func TestPatternAt_Image(t *testing.T) {
+	t.Parallel()
+
+	// 4x2 image, with each column a different shade
+	canvas := NewCanvas(4, 2)
+	for x := range 4 {
+		for y := range 2 {
+			canvas.WritePixel(x, y, White.Multiply(float64(x)/4))
+		}
+	}
+	canvas.WritePixel(0, 0, NewColor(1, 0, 0))
+
+	tests := []struct {
+		name    string
+		mapping string
+		point   *base.Tuple
+		color   *Color
+	}{
+		{
+			name:    "planar; pixel center",
+			mapping: PlanarMapping,
+			point:   base.NewPoint(0.375, 0, 0.25),
+			color:   White.Multiply(0.25),
+		},
+		{
+			name:    "planar; between pixels",
+			mapping: PlanarMapping,
+			point:   base.NewPoint(0.5, 0, 0.25),
+			color:   White.Multiply(0.375),
+		},
+		{
+			name:    "planar; repeats",
+			mapping: PlanarMapping,
+			point:   base.NewPoint(-0.625, 5, 3.25),
+			color:   White.Multiply(0.25),
+		},
+		{
+			name:    "planar; top left",
+			mapping: PlanarMapping,
+			point:   base.NewPoint(0.125, 0, 0.75),
+			color:   NewColor(1, 0, 0),
+		},
+		{
+			name:    "spherical; behind",
+			mapping: SphericalMapping,
+			point:   base.NewPoint(0, 0, 1),
+			color:   White.Multiply(0.375).Add(NewColor(0.25, 0, 0)),
+		},
+		{
+			name:    "spherical; front",
+			mapping: SphericalMapping,
+			point:   base.NewPoint(0, 0, -1),
+			color:   White.Multiply(0.375),
+		},
+		{
+			name:    "spherical; above and to the side",
+			mapping: SphericalMapping,
+			point:   base.NewPoint(-0.7071, 0.7071, 0),
+			color:   White.Multiply(0.625),
+		},
+		{
+			name:    "cylindrical; front",
+			mapping: CylindricalMapping,
+			point:   base.NewPoint(0, 0.75, -1),
+			color:   White.Multiply(0.375),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			ip := NewImagePattern(canvas, test.mapping)
+			g.Expect(ip.PatternAt(test.point).Equals(test.color)).To(BeTrue())
+		})
+	}
+}
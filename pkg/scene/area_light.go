@@ -0,0 +1,124 @@
+package scene
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+)
+
+// AreaLight is a light with a surface (either a rectangle or a disk), which casts soft shadows.
+// The surface is divided into cells, and a sample point is taken from each cell.
+type AreaLight struct {
+	*baseLight
+	origin           *base.Tuple // corner of a rectangle, or center of a disk
+	uvec, vvec       *base.Tuple
+	usteps, vsteps   int
+	samples          int
+	jitter           func(*rand.Rand) float64
+	pointOnLightFunc func(float64, float64, *AreaLight) *base.Tuple
+}
+
+// NewAreaLight returns a new rectangular AreaLight with a corner at the given point. The edges of
+// the rectangle are the u and v vectors, which are divided into usteps and vsteps cells.
+func NewAreaLight(
+	corner, uvec *base.Tuple,
+	usteps int,
+	vvec *base.Tuple,
+	vsteps int,
+	intensity *image.Color,
+) *AreaLight {
+	return newAreaLight(corner, uvec, usteps, vvec, vsteps, intensity, rectanglePointAt)
+}
+
+// NewDiskLight returns a new circular AreaLight centered at the given point. The u and v vectors
+// are the radii of the disk, which is divided into usteps rings and vsteps sectors.
+func NewDiskLight(
+	center, uvec *base.Tuple,
+	usteps int,
+	vvec *base.Tuple,
+	vsteps int,
+	intensity *image.Color,
+) *AreaLight {
+	return newAreaLight(center, uvec, usteps, vvec, vsteps, intensity, diskPointAt)
+}
+
+func newAreaLight(
+	origin, uvec *base.Tuple,
+	usteps int,
+	vvec *base.Tuple,
+	vsteps int,
+	intensity *image.Color,
+	pointFunc func(float64, float64, *AreaLight) *base.Tuple,
+) *AreaLight {
+	return &AreaLight{
+		baseLight:        newBaseLight(intensity),
+		origin:           origin,
+		uvec:             uvec,
+		vvec:             vvec,
+		usteps:           usteps,
+		vsteps:           vsteps,
+		samples:          usteps * vsteps,
+		jitter:           (*rand.Rand).Float64,
+		pointOnLightFunc: pointFunc,
+	}
+}
+
+// SetJitter enables or disables the random placement of sample points within each cell.
+// When disabled, the center of each cell is used.
+func (l *AreaLight) SetJitter(enabled bool) {
+	if enabled {
+		l.jitter = (*rand.Rand).Float64
+	} else {
+		l.jitter = func(*rand.Rand) float64 { return 0.5 }
+	}
+}
+
+// returns the sample point in the (u, v) cell of the light, placed within the cell by the random
+// number generator.
+func (l *AreaLight) pointOnLight(u, v int, rng *rand.Rand) *base.Tuple {
+	// fraction of the way along each vector
+	uFraction := (float64(u) + l.jitter(rng)) / float64(l.usteps)
+	vFraction := (float64(v) + l.jitter(rng)) / float64(l.vsteps)
+
+	return l.pointOnLightFunc(uFraction, vFraction, l)
+}
+
+// rectanglePointAt returns the point on a rectangle light.
+func rectanglePointAt(u, v float64, l *AreaLight) *base.Tuple {
+	return l.origin.Add(l.uvec.Multiply(u)).Add(l.vvec.Multiply(v))
+}
+
+// diskPointAt returns the point on a disk light, where u is the (squared) distance
+// from the center and v is the fraction of a full turn around the center.
+func diskPointAt(u, v float64, l *AreaLight) *base.Tuple {
+	// square root keeps the sample points evenly distributed over the area of the disk
+	radius := math.Sqrt(u)
+	theta := 2 * math.Pi * v
+
+	return l.origin.Add(l.uvec.Multiply(radius * math.Cos(theta))).Add(l.vvec.Multiply(radius * math.Sin(theta)))
+}
+
+// returns a sample from the point to each cell of the light.
+func (l *AreaLight) samplesFrom(point *base.Tuple, rng *rand.Rand) []lightSample {
+	samples := make([]lightSample, 0, l.samples)
+	for v := range l.vsteps {
+		for u := range l.usteps {
+			samples = append(samples, newLightSample(l.pointOnLight(u, v, rng), point))
+		}
+	}
+
+	return samples
+}
+
+// returns the average fraction of each color of the light's samples that reaches the point at
+// the time, through any shadows.
+func (l *AreaLight) intensityAt(point *base.Tuple, time float64, w *World, samples []lightSample) *image.Color {
+	total := image.Black
+	for _, sample := range samples {
+		total = total.Add(w.shadowAt(point, time, sample.from(point)))
+	}
+
+	return total.Multiply(1 / float64(len(samples)))
+}
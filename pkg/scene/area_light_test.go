@@ -0,0 +1,218 @@
+package scene
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/object"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/ray"
+)
+
+// returns a jitter function that cycles through the supplied values.
+func sequence(vals ...float64) func(*rand.Rand) float64 {
+	i := 0
+
+	return func(*rand.Rand) float64 {
+		val := vals[i%len(vals)]
+		i++
+
+		return val
+	}
+}
+
+func TestNewAreaLight(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	corner := base.Origin
+	uvec := base.NewVector(2, 0, 0)
+	vvec := base.NewVector(0, 0, 1)
+	l := NewAreaLight(corner, uvec, 4, vvec, 2, image.White)
+	g.Expect(l.origin).To(Equal(corner))
+	g.Expect(l.uvec).To(Equal(uvec))
+	g.Expect(l.usteps).To(Equal(4))
+	g.Expect(l.vvec).To(Equal(vvec))
+	g.Expect(l.vsteps).To(Equal(2))
+	g.Expect(l.samples).To(Equal(8))
+	g.Expect(l.GetIntensity()).To(Equal(image.White))
+}
+
+func TestPointOnLight(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		u, v     int
+		expPoint *base.Tuple
+	}{
+		{u: 0, v: 0, expPoint: base.NewPoint(0.25, 0, 0.25)},
+		{u: 1, v: 0, expPoint: base.NewPoint(0.75, 0, 0.25)},
+		{u: 0, v: 1, expPoint: base.NewPoint(0.25, 0, 0.75)},
+		{u: 2, v: 0, expPoint: base.NewPoint(1.25, 0, 0.25)},
+		{u: 3, v: 1, expPoint: base.NewPoint(1.75, 0, 0.75)},
+	}
+
+	l := NewAreaLight(base.Origin, base.NewVector(2, 0, 0), 4, base.NewVector(0, 0, 1), 2, image.White)
+	l.SetJitter(false)
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			g.Expect(l.pointOnLight(test.u, test.v, testRand())).To(Equal(test.expPoint))
+		})
+	}
+
+	// with jitter
+	g := NewWithT(t)
+	jittered := NewAreaLight(base.Origin, base.NewVector(2, 0, 0), 4, base.NewVector(0, 0, 1), 2, image.White)
+	jittered.jitter = sequence(0.3, 0.7)
+	g.Expect(jittered.pointOnLight(0, 0, testRand())).To(Equal(base.NewPoint(0.15, 0, 0.35)))
+	g.Expect(jittered.pointOnLight(2, 1, testRand())).To(Equal(base.NewPoint(1.15, 0, 0.85)))
+}
+
+func TestPointOnLight_Disk(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	l := NewDiskLight(base.NewPoint(0, 5, 0), base.NewVector(2, 0, 0), 2, base.NewVector(0, 0, 2), 4, image.White)
+	l.jitter = sequence(0)
+	g.Expect(l.pointOnLight(0, 0, testRand())).To(Equal(base.NewPoint(0, 5, 0)))
+	g.Expect(l.pointOnLight(1, 0, testRand())).To(Equal(base.NewPoint(math.Sqrt(2), 5, 0)))
+	g.Expect(l.pointOnLight(1, 1, testRand()).Equals(base.NewPoint(0, 5, math.Sqrt(2)))).To(BeTrue())
+
+	// all points are within the disk
+	l.SetJitter(true)
+	for v := range l.vsteps {
+		for u := range l.usteps {
+			p := l.pointOnLight(u, v, testRand())
+			g.Expect(p.Subtract(l.origin).Magnitude()).To(BeNumerically("<=", 2))
+			g.Expect(p.GetY()).To(Equal(5.0))
+		}
+	}
+	g.Expect(l.samplesFrom(base.Origin, testRand())).To(HaveLen(8))
+}
+
+func TestAreaLightIntensityAt(t *testing.T) {
+	t.Parallel()
+	worldTestSetup()
+
+	tests := []struct {
+		point        *base.Tuple
+		expIntensity float64
+	}{
+		{point: base.NewPoint(0, 0, 2), expIntensity: 0},
+		{point: base.NewPoint(1, -1, 2), expIntensity: 0.25},
+		{point: base.NewPoint(1.5, 0, 2), expIntensity: 0.5},
+		{point: base.NewPoint(1.25, 1.25, 3), expIntensity: 0.75},
+		{point: base.NewPoint(0, 0, -2), expIntensity: 1},
+	}
+
+	w := NewWorld(testLights, testObjects)
+	corner := base.NewPoint(-0.5, -0.5, -5)
+	l := NewAreaLight(corner, base.NewVector(1, 0, 0), 2, base.NewVector(0, 1, 0), 2, image.White)
+	l.SetJitter(false)
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			g.Expect(reaching(l, test.point, w)).To(Equal(gray(test.expIntensity)))
+		})
+	}
+}
+
+func TestPointLightIntensityAt(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	worldTestSetup()
+
+	w := NewWorld(testLights, testObjects)
+	g.Expect(reaching(w.lights[0], base.NewPoint(0, 1.0001, 0), w)).To(Equal(image.White))
+	g.Expect(reaching(w.lights[0], base.NewPoint(-1.0001, 0, 0), w)).To(Equal(image.White))
+	g.Expect(reaching(w.lights[0], base.NewPoint(0, 0, -1.0001), w)).To(Equal(image.White))
+	g.Expect(reaching(w.lights[0], base.NewPoint(0, 0, 1.0001), w)).To(Equal(image.Black))
+	g.Expect(reaching(w.lights[0], base.NewPoint(1.0001, 0, 0), w)).To(Equal(image.Black))
+	g.Expect(reaching(w.lights[0], base.NewPoint(0, -1.0001, 0), w)).To(Equal(image.Black))
+	g.Expect(reaching(w.lights[0], base.NewPoint(0, 0, 0), w)).To(Equal(image.Black))
+}
+
+func TestLighting_AreaLight(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		point    *base.Tuple
+		expColor *image.Color
+	}{
+		{
+			point:    base.NewPoint(0, 0, -1),
+			expColor: image.NewColor(0.9965048411651541, 0.9965048411651541, 0.9965048411651541),
+		},
+		{
+			point:    base.NewPoint(0, 0.7071, -0.7071),
+			expColor: image.NewColor(0.6231828237025393, 0.6231828237025393, 0.6231828237025393),
+		},
+	}
+
+	corner := base.NewPoint(-0.5, -0.5, -5)
+	l := NewAreaLight(corner, base.NewVector(1, 0, 0), 2, base.NewVector(0, 1, 0), 2, image.White)
+	l.SetJitter(false)
+	s := object.NewSphere()
+	m := object.DefaultMaterial
+	m.Ambient = 0.1
+	m.Diffuse = 0.9
+	m.Specular = 0
+	eye := base.NewPoint(0, 0, -5)
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			eyev := eye.Subtract(test.point).Normalize()
+			normalv := base.NewVector(test.point.GetX(), test.point.GetY(), test.point.GetZ())
+			result := lighting(l, s, &m, test.point, eyev, normalv, 0, l.samplesFrom(test.point, testRand()), image.White)
+			g.Expect(result).To(Equal(test.expColor))
+		})
+	}
+}
+
+func TestAreaLight_Repeatable(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	l := NewAreaLight(base.NewPoint(-1, 3, -1), base.NewVector(2, 0, 0), 4, base.NewVector(0, 0, 2), 4, image.White)
+	point := base.NewPoint(0, 0, 0)
+
+	// the jittered samples only depend on the random number generator
+	g.Expect(l.samplesFrom(point, testRand())).To(Equal(l.samplesFrom(point, testRand())))
+	g.Expect(l.samplesFrom(point, rand.New(rand.NewPCG(3, 4)))).ToNot(Equal(l.samplesFrom(point, testRand())))
+
+	// a hit's shadows and shading use the same samples
+	floor := object.NewPlane()
+	ball := object.NewSphere()
+	ball.SetTransform(base.Translate(0.5, 1.5, 0), base.Scale(0.5, 0.5, 0.5))
+	w := NewWorld([]Light{l}, []object.Object{floor, ball})
+	r := ray.NewRay(base.NewPoint(0, 1, -1), base.NewVector(0, -1, 1).Normalize())
+	ints := w.intersect(r)
+	hd := prepareComputations(object.Hit(ints), r, ints)
+	hd.rng = testRand()
+	hit := w.newHit(hd, floor.GetMaterial(), 0)
+	directions, _ := hit.LightDirections(l)
+	samples := l.samplesFrom(hd.point, testRand())
+	for i, sample := range samples {
+		g.Expect(directions[i]).To(Equal(sample.direction))
+	}
+	g.Expect(hit.LightReaching(l)).To(Equal(l.intensityAt(hd.overPoint, 0, w, samples)))
+	again, _ := hit.LightDirections(l)
+	g.Expect(again).To(Equal(directions))
+
+	// renders with a jittered light are the same every time, and for any number of workers
+	c := NewCamera(12, 8, math.Pi/3)
+	c.SetTransform(base.ViewTransform(base.NewPoint(0, 2, -4), base.Origin, base.NewVector(0, 1, 0)))
+	canvas := RenderWithOptions(c, w, RenderOptions{Workers: 1, TileSize: 1})
+	g.Expect(RenderWithOptions(c, w, RenderOptions{Workers: 4, TileSize: 3, Order: SpiralOrder})).To(Equal(canvas))
+}
@@ -0,0 +1,30 @@
+package scene
+
+import (
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+)
+
+// NewSolidBackground returns a background pattern that is the same color in every direction.
+func NewSolidBackground(color *image.Color) image.Pattern {
+	return image.NewPattern(color, color, func(_ *base.Tuple, p *image.PatternObject) *image.Color {
+		return p.GetColors()[0]
+	})
+}
+
+// NewGradientBackground returns a background pattern that blends from the bottom color straight
+// down to the top color straight up.
+func NewGradientBackground(bottom, top *image.Color) image.Pattern {
+	return image.NewPattern(bottom, top, func(direction *base.Tuple, p *image.PatternObject) *image.Color {
+		colors := p.GetColors()
+		fraction := (direction.GetY() + 1) / 2
+
+		return colors[0].Add(colors[1].Subtract(colors[0]).Multiply(fraction))
+	})
+}
+
+// NewImageBackground returns a background pattern that wraps an equirectangular image around the
+// scene, with the center of the image in the -z direction (as rendered by an equirectangular camera).
+func NewImageBackground(canvas *image.Canvas) image.Pattern {
+	return image.NewImagePattern(canvas, image.SphericalMapping)
+}
@@ -0,0 +1,74 @@
+package scene
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+)
+
+func TestBackgrounds(t *testing.T) {
+	t.Parallel()
+
+	canvas := image.NewCanvas(4, 2)
+	// the center of the image is straight ahead, in the -z direction
+	canvas.WritePixel(1, 0, image.NewColor(1, 0, 0))
+	canvas.WritePixel(2, 0, image.NewColor(1, 0, 0))
+	canvas.WritePixel(1, 1, image.NewColor(1, 0, 0))
+	canvas.WritePixel(2, 1, image.NewColor(1, 0, 0))
+
+	tests := []struct {
+		name       string
+		background image.Pattern
+		direction  *base.Tuple
+		expColor   *image.Color
+	}{
+		{
+			name:       "solid",
+			background: NewSolidBackground(image.NewColor(0.2, 0.4, 0.6)),
+			direction:  base.NewVector(0, 0.6, 0.8),
+			expColor:   image.NewColor(0.2, 0.4, 0.6),
+		},
+		{
+			name:       "gradient straight up",
+			background: NewGradientBackground(image.White, image.NewColor(0, 0, 1)),
+			direction:  base.NewVector(0, 1, 0),
+			expColor:   image.NewColor(0, 0, 1),
+		},
+		{
+			name:       "gradient straight down",
+			background: NewGradientBackground(image.White, image.NewColor(0, 0, 1)),
+			direction:  base.NewVector(0, -1, 0),
+			expColor:   image.White,
+		},
+		{
+			name:       "gradient at the horizon",
+			background: NewGradientBackground(image.White, image.NewColor(0, 0, 1)),
+			direction:  base.NewVector(1, 0, 0),
+			expColor:   image.NewColor(0.5, 0.5, 1),
+		},
+		{
+			name:       "image ahead",
+			background: NewImageBackground(canvas),
+			direction:  base.NewVector(0, 0, -1),
+			expColor:   image.NewColor(1, 0, 0),
+		},
+		{
+			name:       "image behind",
+			background: NewImageBackground(canvas),
+			direction:  base.NewVector(0, 0, 1),
+			expColor:   image.Black,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			g.Expect(test.background.PatternAt(test.direction).Equals(test.expColor)).To(BeTrue())
+		})
+	}
+}
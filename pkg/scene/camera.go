@@ -2,30 +2,53 @@ package scene
 
 import (
 	"math"
+	"math/rand/v2"
 
 	"github.com/sjberman/golang-ray-tracer/pkg/base"
 	"github.com/sjberman/golang-ray-tracer/pkg/scene/ray"
 )
 
+// Sampling strategies for placing multiple rays within a pixel (anti-aliasing).
+const (
+	GridSampling     = "grid"     // evenly spaced grid
+	JitteredSampling = "jittered" // random point within each cell of a grid
+	RandomSampling   = "random"   // random points anywhere in the pixel
+)
+
+// Projections for how the camera maps the scene onto the canvas.
+const (
+	PerspectiveProjection     = "perspective"     // rays spread out from a single point
+	OrthographicProjection    = "orthographic"    // parallel rays
+	EquirectangularProjection = "equirectangular" // 360 degrees of longitude by 180 degrees of latitude
+	FisheyeProjection         = "fisheye"         // equidistant angles from the center of a circle
+	CylindricalProjection     = "cylindrical"     // 360 degrees around, with a perspective vertical axis
+)
+
 // Camera is the viewpoint of a scene.
 type Camera struct {
 	hsize       int // horizontal size
 	vsize       int // vertical size
+	projection  string
 	fieldOfView float64
 	pixelSize   float64
 	halfWidth   float64
 	halfHeight  float64
 	transform   *base.Matrix
+	samples     int // rays per pixel
+	sampling    string
+	// thin lens for depth of field; an aperture of 0 is a perfect pinhole
+	aperture      float64 // radius of the lens
+	focalDistance float64 // distance from the camera to the plane in perfect focus
+	blades        int     // number of sides of the lens, or 0 for a circle
+	// times (from 0 to 1) that the shutter opens and closes, for motion blur
+	shutterOpen, shutterClose float64
 }
 
-// NewCamera returns a new Camera object.
+// NewCamera returns a new perspective Camera object.
 func NewCamera(hsize, vsize int, fieldOfView float64) *Camera {
-	c := &Camera{
-		hsize:       hsize,
-		vsize:       vsize,
-		fieldOfView: fieldOfView,
-		transform:   &base.Identity,
-	}
+	c := newCamera(hsize, vsize, PerspectiveProjection)
+	c.fieldOfView = fieldOfView
+
 	halfView := math.Tan(fieldOfView / 2)
 	aspect := float64(hsize) / float64(vsize)
 	if aspect >= 1 {
@@ -40,29 +63,300 @@ func NewCamera(hsize, vsize int, fieldOfView float64) *Camera {
 	return c
 }
 
+// NewOrthographicCamera returns a new Camera object with parallel rays, where the
+// view width is the width of the scene (in world units) that fits across the canvas.
+func NewOrthographicCamera(hsize, vsize int, viewWidth float64) *Camera {
+	c := newCamera(hsize, vsize, OrthographicProjection)
+	c.halfWidth = viewWidth / 2
+	c.halfHeight = c.halfWidth * float64(vsize) / float64(hsize)
+	c.pixelSize = viewWidth / float64(hsize)
+
+	return c
+}
+
+// NewPanoramicCamera returns a new Camera object using the equirectangular, fisheye, or cylindrical
+// projection. The field of view is the angle across the circle of a fisheye projection, and is ignored
+// by the others, which always see all the way around the camera.
+//
+// The center of the canvas is the direction the camera faces. An equirectangular render from an
+// untransformed camera can be used directly as an environment map.
+func NewPanoramicCamera(hsize, vsize int, projection string, fieldOfView float64) *Camera {
+	c := newCamera(hsize, vsize, projection)
+	c.fieldOfView = fieldOfView
+
+	return c
+}
+
+func newCamera(hsize, vsize int, projection string) *Camera {
+	return &Camera{
+		hsize:      hsize,
+		vsize:      vsize,
+		projection: projection,
+		transform:  &base.Identity,
+		samples:    1,
+		sampling:   GridSampling,
+	}
+}
+
 // SetTransform sets the transform matrix of the camera.
 func (c *Camera) SetTransform(matrix *base.Matrix) {
 	c.transform = matrix
 }
 
-// RayForPixel returns a ray starting at the camera and going to x,y on the canvas.
+// SetSampling sets the number of rays per pixel and how they are placed within the pixel.
+// The grid and jittered strategies round the samples down to a square number.
+func (c *Camera) SetSampling(samples int, sampling string) {
+	c.samples = max(samples, 1)
+	c.sampling = sampling
+}
+
+// SetFocus turns the camera into a thin lens with the given aperture radius, which keeps objects
+// at the focal distance sharp and blurs everything nearer or farther. The lens is a circle, or a
+// regular polygon if it has at least 3 blades, which determines the shape of out of focus highlights.
+func (c *Camera) SetFocus(aperture, focalDistance float64, blades int) {
+	c.aperture = max(aperture, 0)
+	c.focalDistance = focalDistance
+	c.blades = blades
+}
+
+// SetShutter sets the times (from 0 to 1) that the camera's shutter opens and closes. Rays are
+// sent at times within the interval, so objects that move during it are blurred.
+func (c *Camera) SetShutter(open, closed float64) {
+	c.shutterOpen = open
+	c.shutterClose = max(closed, open)
+}
+
+// RayForPixel returns a ray starting at the camera and going to the center of x,y on the canvas.
+// If the camera has an aperture or a shutter interval, the ray's point on the lens and time
+// are seeded by the pixel.
+// Returns nil if the projection doesn't cover the pixel (outside the circle of a fisheye).
 func (c *Camera) RayForPixel(x, y int) *ray.Ray {
-	// the offset from the edge of the canvas to the pixel's center
-	xOffset := (float64(x) + 0.5) * c.pixelSize
-	yOffset := (float64(y) + 0.5) * c.pixelSize
+	return c.rayForPixelSample(x, y, c.centerSample(x, y))
+}
+
+// returns a ray starting at the camera and going through the sample of x,y on the canvas.
+func (c *Camera) rayForPixelSample(x, y int, sample pixelSample) *ray.Ray {
+	switch c.projection {
+	case EquirectangularProjection, FisheyeProjection, CylindricalProjection:
+		return c.panoramicRay(x, y, sample)
+	}
+
+	// the offset from the edge of the canvas to the point within the pixel
+	xOffset := (float64(x) + sample.x) * c.pixelSize
+	yOffset := (float64(y) + sample.y) * c.pixelSize
 
 	// the untransformed coordinates of the pixel in world space.
 	// (camera looks towards -z, so +x is to the left)
 	worldX := c.halfWidth - xOffset
 	worldY := c.halfHeight - yOffset
 
+	// (canvas is at z = -1)
+	pixel := base.NewPoint(worldX, worldY, -1)
+	origin := base.Origin
+	if c.projection == OrthographicProjection {
+		// rays start at the pixel's position on the camera's plane, and all face the same way
+		origin = base.NewPoint(worldX, worldY, 0)
+	}
+	if c.aperture > 0 {
+		// every ray through the lens for this pixel converges on the same point of the focal plane
+		if c.projection == OrthographicProjection {
+			pixel = base.NewPoint(worldX, worldY, -c.focalDistance)
+		} else {
+			pixel = base.NewPoint(worldX*c.focalDistance, worldY*c.focalDistance, -c.focalDistance)
+		}
+		lensX, lensY := c.pointOnLens(sample.lensU, sample.lensV)
+		origin = origin.Add(base.NewVector(lensX, lensY, 0))
+	}
+
 	// using the camera matrix, transform the canvas point and the origin,
 	// and then compute the ray's direction vector
-	// (canvas is at z = -1)
 	inverse := c.transform.Inverse()
-	pixel := inverse.MultiplyTuple(base.NewPoint(worldX, worldY, -1))
-	origin := inverse.MultiplyTuple(base.Origin)
+	pixel = inverse.MultiplyTuple(pixel)
+	origin = inverse.MultiplyTuple(origin)
 	direction := pixel.Subtract(origin).Normalize()
 
-	return ray.NewRay(origin, direction)
+	r := ray.NewRay(origin, direction)
+	r.Time = sample.time
+
+	return r
+}
+
+// returns a ray starting at the camera and going in the direction the panoramic projection
+// maps the sample of x,y to. Panoramic projections have no lens, so the aperture is ignored.
+func (c *Camera) panoramicRay(x, y int, sample pixelSample) *ray.Ray {
+	// fraction of the way across and down the canvas
+	u := (float64(x) + sample.x) / float64(c.hsize)
+	v := (float64(y) + sample.y) / float64(c.vsize)
+
+	var direction *base.Tuple
+	switch c.projection {
+	case EquirectangularProjection:
+		direction = equirectangularDirection(u, v)
+	case CylindricalProjection:
+		// pixels are square, so the height of the canvas covers the same angle per pixel as the width
+		height := (0.5 - v) * 2 * math.Pi * float64(c.vsize) / float64(c.hsize)
+		longitude := (u - 0.5) * 2 * math.Pi
+		direction = base.NewVector(-math.Sin(longitude), height, -math.Cos(longitude)).Normalize()
+	case FisheyeProjection:
+		// distance from the center of the canvas, where the largest circle that fits is 1
+		radius := float64(min(c.hsize, c.vsize)) / 2
+		dx := (u - 0.5) * float64(c.hsize) / radius
+		dy := (0.5 - v) * float64(c.vsize) / radius
+		distance := math.Hypot(dx, dy)
+		if distance > 1 {
+			return nil
+		}
+		// the angle from the center is proportional to the distance from the center
+		angle := distance * c.fieldOfView / 2
+		direction = base.NewVector(0, 0, -1)
+		if distance > 0 {
+			sin := math.Sin(angle) / distance
+			direction = base.NewVector(-dx*sin, dy*sin, -math.Cos(angle))
+		}
+	}
+
+	inverse := c.transform.Inverse()
+	r := ray.NewRay(inverse.MultiplyTuple(base.Origin), inverse.MultiplyTuple(direction).Normalize())
+	r.Time = sample.time
+
+	return r
+}
+
+// returns the direction (in camera space) for the u and v fractions (from 0 to 1) of an
+// equirectangular image, where the center of the image faces -z.
+func equirectangularDirection(u, v float64) *base.Tuple {
+	longitude := (u - 0.5) * 2 * math.Pi
+	latitude := (0.5 - v) * math.Pi
+
+	// (camera looks towards -z, so +x is to the left)
+	return base.NewVector(
+		-math.Sin(longitude)*math.Cos(latitude),
+		math.Sin(latitude),
+		-math.Cos(longitude)*math.Cos(latitude),
+	)
+}
+
+// returns the point on the lens (in camera space) for the u and v fractions (from 0 to 1).
+// Points are evenly distributed over the area of the lens.
+func (c *Camera) pointOnLens(u, v float64) (float64, float64) {
+	// square root keeps the points evenly distributed over the area
+	radius := c.aperture * math.Sqrt(u)
+
+	if c.blades < 3 {
+		theta := 2 * math.Pi * v
+
+		return radius * math.Cos(theta), radius * math.Sin(theta)
+	}
+
+	// pick the triangle (between the center and an edge of the polygon) using v,
+	// and interpolate between the corners of that edge
+	blade := math.Floor(v * float64(c.blades))
+	t := v*float64(c.blades) - blade
+	angle := 2 * math.Pi / float64(c.blades)
+	x1, y1 := math.Cos(blade*angle), math.Sin(blade*angle)
+	x2, y2 := math.Cos((blade+1)*angle), math.Sin((blade+1)*angle)
+
+	return radius * ((1-t)*x1 + t*x2), radius * ((1-t)*y1 + t*y2)
+}
+
+// pixelSample is an offset (from 0 to 1) from the corner of a pixel, the u and v
+// fractions (from 0 to 1) of a point on the camera's lens, and the time of the ray.
+type pixelSample struct {
+	x, y         float64
+	lensU, lensV float64
+	time         float64
+}
+
+// returns a sample through the center of the pixel.
+func (c *Camera) centerSample(x, y int) pixelSample {
+	sample := pixelSample{x: 0.5, y: 0.5, time: c.shutterOpen}
+	if c.aperture > 0 || c.shutterClose > c.shutterOpen {
+		rng := pixelRand(x, y)
+		c.sampleLensAndTime(&sample, rng)
+	}
+
+	return sample
+}
+
+// sets the point on the lens and the time of the sample, if the camera has an aperture
+// or a shutter interval.
+func (c *Camera) sampleLensAndTime(sample *pixelSample, rng *rand.Rand) {
+	if c.aperture > 0 {
+		sample.lensU, sample.lensV = rng.Float64(), rng.Float64()
+	}
+	sample.time = c.shutterOpen
+	if c.shutterClose > c.shutterOpen {
+		sample.time += rng.Float64() * (c.shutterClose - c.shutterOpen)
+	}
+}
+
+// returns the sample for a pass of a progressive render. The first pass is through the center of
+// the pixel, and later passes are at a random point, seeded by the pixel's location and the pass.
+func (c *Camera) passSample(x, y, pass int) pixelSample {
+	if pass == 0 {
+		return c.centerSample(x, y)
+	}
+	rng := rand.New(rand.NewPCG(uint64(x)<<32|uint64(y), uint64(pass)))
+	sample := pixelSample{x: rng.Float64(), y: rng.Float64()}
+	c.sampleLensAndTime(&sample, rng)
+
+	return sample
+}
+
+// returns a random number generator seeded by the pixel's location.
+func pixelRand(x, y int) *rand.Rand {
+	return rand.New(rand.NewPCG(uint64(x), uint64(y)))
+}
+
+// returns the offsets within the pixel that rays should be sent through. Random offsets
+// are seeded by the pixel's location so that renders are repeatable.
+func (c *Camera) pixelSamples(x, y int) []pixelSample {
+	if c.samples <= 1 {
+		return []pixelSample{c.centerSample(x, y)}
+	}
+	rng := pixelRand(x, y)
+
+	var samples []pixelSample
+	if c.sampling == RandomSampling {
+		samples = c.randomSamples(rng)
+	} else {
+		samples = c.gridSamples(rng)
+	}
+
+	for i := range samples {
+		c.sampleLensAndTime(&samples[i], rng)
+	}
+
+	return samples
+}
+
+// returns samples at random points anywhere in the pixel.
+func (c *Camera) randomSamples(rng *rand.Rand) []pixelSample {
+	samples := make([]pixelSample, 0, c.samples)
+	for range c.samples {
+		samples = append(samples, pixelSample{x: rng.Float64(), y: rng.Float64()})
+	}
+
+	return samples
+}
+
+// returns samples within each cell of a square grid, at the center of the cell or
+// at a random point if jittered.
+func (c *Camera) gridSamples(rng *rand.Rand) []pixelSample {
+	n := int(math.Sqrt(float64(c.samples)))
+	samples := make([]pixelSample, 0, n*n)
+	for j := range n {
+		for i := range n {
+			xJitter, yJitter := 0.5, 0.5
+			if c.sampling == JitteredSampling {
+				xJitter, yJitter = rng.Float64(), rng.Float64()
+			}
+			samples = append(samples, pixelSample{
+				x: (float64(i) + xJitter) / float64(n),
+				y: (float64(j) + yJitter) / float64(n),
+			})
+		}
+	}
+
+	return samples
 }
@@ -8,6 +8,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
 )
 
 func TestNewCamera(t *testing.T) {
@@ -54,3 +55,320 @@ func TestRayForPixel(t *testing.T) {
 	expVector := base.NewVector(math.Sqrt(2)/2, 0, -math.Sqrt(2)/2)
 	g.Expect(ray.Direction.Equals(expVector)).To(BeTrue(), fmt.Sprintf("%v", ray.Direction))
 }
+
+func TestPixelSamples(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// single sample is the center of the pixel
+	c := NewCamera(10, 10, math.Pi/2)
+	g.Expect(c.pixelSamples(1, 2)).To(Equal([]pixelSample{{x: 0.5, y: 0.5}}))
+
+	c.SetSampling(0, RandomSampling)
+	g.Expect(c.samples).To(Equal(1))
+	g.Expect(c.pixelSamples(1, 2)).To(Equal([]pixelSample{{x: 0.5, y: 0.5}}))
+
+	// grid
+	c.SetSampling(5, GridSampling)
+	g.Expect(c.pixelSamples(1, 2)).To(Equal([]pixelSample{
+		{x: 0.25, y: 0.25}, {x: 0.75, y: 0.25}, {x: 0.25, y: 0.75}, {x: 0.75, y: 0.75},
+	}))
+
+	// jittered stays within each cell of the grid
+	c.SetSampling(9, JitteredSampling)
+	samples := c.pixelSamples(1, 2)
+	g.Expect(samples).To(HaveLen(9))
+	for i, s := range samples {
+		col, row := float64(i%3), float64(i/3)
+		g.Expect(s.x).To(BeNumerically(">=", col/3))
+		g.Expect(s.x).To(BeNumerically("<", (col+1)/3))
+		g.Expect(s.y).To(BeNumerically(">=", row/3))
+		g.Expect(s.y).To(BeNumerically("<", (row+1)/3))
+	}
+	// repeatable for the same pixel, but different for another pixel
+	g.Expect(c.pixelSamples(1, 2)).To(Equal(samples))
+	g.Expect(c.pixelSamples(2, 1)).ToNot(Equal(samples))
+
+	// random
+	c.SetSampling(5, RandomSampling)
+	samples = c.pixelSamples(1, 2)
+	g.Expect(samples).To(HaveLen(5))
+	for _, s := range samples {
+		g.Expect(s.x).To(BeNumerically(">=", 0))
+		g.Expect(s.x).To(BeNumerically("<", 1))
+		g.Expect(s.y).To(BeNumerically(">=", 0))
+		g.Expect(s.y).To(BeNumerically("<", 1))
+	}
+}
+
+func TestRayForPixelSample(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	c := NewCamera(201, 101, math.Pi/2)
+	g.Expect(c.rayForPixelSample(100, 50, pixelSample{x: 0.5, y: 0.5})).To(Equal(c.RayForPixel(100, 50)))
+
+	// offset to the corner of the pixel
+	ray := c.rayForPixelSample(0, 0, pixelSample{x: 0, y: 0})
+	g.Expect(ray.Origin).To(Equal(base.Origin))
+	g.Expect(ray.Direction.Equals(base.NewVector(c.halfWidth, c.halfHeight, -1).Normalize())).To(BeTrue())
+}
+
+func TestPointOnLens(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// circular lens
+	c := NewCamera(10, 10, math.Pi/2)
+	c.SetFocus(2, 5, 0)
+	x, y := c.pointOnLens(0, 0.3)
+	g.Expect([]float64{x, y}).To(Equal([]float64{0, 0}))
+	x, y = c.pointOnLens(1, 0)
+	g.Expect([]float64{x, y}).To(Equal([]float64{2, 0}))
+	x, y = c.pointOnLens(0.25, 0.25)
+	g.Expect(x).To(BeNumerically("~", 0))
+	g.Expect(y).To(BeNumerically("~", 1))
+
+	// hexagonal lens
+	c.SetFocus(2, 5, 6)
+	x, y = c.pointOnLens(1, 0)
+	g.Expect([]float64{x, y}).To(Equal([]float64{2, 0}))
+	// halfway along the first edge
+	x, y = c.pointOnLens(1, 1.0/12)
+	g.Expect(x).To(BeNumerically("~", 1.5))
+	g.Expect(y).To(BeNumerically("~", math.Sqrt(3)/2))
+
+	// all points are within the polygon
+	apothem := 2 * math.Cos(math.Pi/6)
+	for i := range 100 {
+		x, y = c.pointOnLens(float64(i%10)/10, float64(i/10)/10)
+		angle := math.Atan2(y, x)
+		// angle from the middle of the nearest edge
+		offset := math.Mod(angle+2*math.Pi, math.Pi/3) - math.Pi/6
+		g.Expect(math.Hypot(x, y) * math.Cos(offset)).To(BeNumerically("<=", apothem+base.Epsilon))
+	}
+}
+
+func TestRayForPixel_DepthOfField(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	c := NewCamera(201, 101, math.Pi/2)
+	c.SetFocus(0.5, 4, 0)
+	c.SetSampling(16, JitteredSampling)
+	c.SetTransform(base.Translate(0, 0, -2))
+
+	// rays through the center of the canvas start on the lens and meet on the focal plane
+	focalPoint := base.NewPoint(0, 0, -2)
+	origins := map[string]bool{}
+	for _, sample := range c.pixelSamples(100, 50) {
+		g.Expect(sample.lensU).To(BeNumerically(">=", 0))
+		g.Expect(sample.lensV).To(BeNumerically(">=", 0))
+
+		r := c.rayForPixelSample(100, 50, pixelSample{x: 0.5, y: 0.5, lensU: sample.lensU, lensV: sample.lensV})
+		g.Expect(r.Origin.GetZ()).To(Equal(2.0))
+		g.Expect(r.Origin.Subtract(base.NewPoint(0, 0, 2)).Magnitude()).To(BeNumerically("<=", 0.5))
+		g.Expect(focalPoint.Subtract(r.Origin).Normalize().Equals(r.Direction)).To(BeTrue())
+		origins[fmt.Sprintf("%v", r.Origin)] = true
+	}
+	g.Expect(len(origins)).To(BeNumerically(">", 1))
+
+	// the center ray is repeatable
+	g.Expect(c.RayForPixel(100, 50)).To(Equal(c.RayForPixel(100, 50)))
+	r := c.RayForPixel(100, 50)
+	g.Expect(focalPoint.Subtract(r.Origin).Normalize().Equals(r.Direction)).To(BeTrue())
+}
+
+func TestRayForPixel_Orthographic(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	c := NewOrthographicCamera(200, 100, 10)
+	g.Expect(c.projection).To(Equal(OrthographicProjection))
+	g.Expect(c.halfWidth).To(Equal(5.0))
+	g.Expect(c.halfHeight).To(Equal(2.5))
+	g.Expect(c.pixelSize).To(Equal(0.05))
+
+	// all rays are parallel, starting from the pixel's position on the camera's plane
+	ray := c.RayForPixel(100, 50)
+	g.Expect(ray.Origin.Equals(base.NewPoint(-0.025, -0.025, 0))).To(BeTrue())
+	g.Expect(ray.Direction).To(Equal(base.NewVector(0, 0, -1)))
+
+	ray = c.RayForPixel(0, 0)
+	g.Expect(ray.Origin.Equals(base.NewPoint(4.975, 2.475, 0))).To(BeTrue())
+	g.Expect(ray.Direction).To(Equal(base.NewVector(0, 0, -1)))
+
+	// when camera is transformed
+	c.SetTransform(base.ViewTransform(base.NewPoint(0, 0, -5), base.Origin, base.NewVector(0, 1, 0)))
+	ray = c.RayForPixel(0, 0)
+	g.Expect(ray.Origin.Equals(base.NewPoint(-4.975, 2.475, -5))).To(BeTrue(), fmt.Sprintf("%v", ray.Origin))
+	g.Expect(ray.Direction.Equals(base.NewVector(0, 0, 1))).To(BeTrue(), fmt.Sprintf("%v", ray.Direction))
+}
+
+func TestRayForPixel_Panoramic(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		camera       *Camera
+		x, y         int
+		sample       pixelSample
+		expDirection *base.Tuple
+	}{
+		{
+			name:         "equirectangular center",
+			camera:       NewPanoramicCamera(4, 2, EquirectangularProjection, 0),
+			x:            2,
+			y:            1,
+			expDirection: base.NewVector(0, 0, -1),
+		},
+		{
+			name:         "equirectangular left edge",
+			camera:       NewPanoramicCamera(4, 2, EquirectangularProjection, 0),
+			x:            0,
+			y:            1,
+			expDirection: base.NewVector(0, 0, 1),
+		},
+		{
+			name:         "equirectangular quarter turn",
+			camera:       NewPanoramicCamera(4, 2, EquirectangularProjection, 0),
+			x:            1,
+			y:            1,
+			expDirection: base.NewVector(1, 0, 0),
+		},
+		{
+			name:         "equirectangular top",
+			camera:       NewPanoramicCamera(4, 2, EquirectangularProjection, 0),
+			x:            2,
+			y:            0,
+			expDirection: base.NewVector(0, 1, 0),
+		},
+		{
+			name:         "fisheye center",
+			camera:       NewPanoramicCamera(4, 4, FisheyeProjection, math.Pi),
+			x:            2,
+			y:            2,
+			expDirection: base.NewVector(0, 0, -1),
+		},
+		{
+			name:         "fisheye right edge",
+			camera:       NewPanoramicCamera(4, 4, FisheyeProjection, math.Pi),
+			x:            4,
+			y:            2,
+			expDirection: base.NewVector(-1, 0, 0),
+		},
+		{
+			name:         "fisheye halfway to the top",
+			camera:       NewPanoramicCamera(4, 4, FisheyeProjection, math.Pi),
+			x:            2,
+			y:            1,
+			expDirection: base.NewVector(0, math.Sqrt(2)/2, -math.Sqrt(2)/2),
+		},
+		{
+			name:         "cylindrical three quarter turn",
+			camera:       NewPanoramicCamera(4, 2, CylindricalProjection, 0),
+			x:            3,
+			y:            1,
+			expDirection: base.NewVector(-1, 0, 0),
+		},
+		{
+			name:         "cylindrical top",
+			camera:       NewPanoramicCamera(4, 2, CylindricalProjection, 0),
+			x:            2,
+			y:            0,
+			expDirection: base.NewVector(0, math.Pi/2, -1).Normalize(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			// samples at the corner of the pixel
+			ray := test.camera.rayForPixelSample(test.x, test.y, test.sample)
+			g.Expect(ray.Origin).To(Equal(base.Origin))
+			g.Expect(ray.Direction.Equals(test.expDirection)).To(BeTrue(), fmt.Sprintf("%v", ray.Direction))
+		})
+	}
+}
+
+func TestRayForPixel_FisheyeOutsideCircle(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	c := NewPanoramicCamera(4, 4, FisheyeProjection, math.Pi)
+	g.Expect(c.RayForPixel(0, 0)).To(BeNil())
+	g.Expect(c.RayForPixel(1, 1)).ToNot(BeNil())
+
+	// transformed camera
+	c.SetTransform(base.ViewTransform(base.NewPoint(0, 0, -5), base.Origin, base.NewVector(0, 1, 0)))
+	ray := c.RayForPixel(2, 2)
+	g.Expect(ray.Origin).To(Equal(base.NewPoint(0, 0, -5)))
+
+	// pixels without a ray are black
+	worldTestSetup()
+	w := NewWorld(testLights, testObjects)
+	g.Expect(w.colorAtPixel(c, 0, 0)).To(Equal(image.Black))
+}
+
+func TestPixelSamples_Shutter(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// rays are sent when the shutter opens by default
+	c := NewCamera(10, 10, math.Pi/2)
+	g.Expect(c.RayForPixel(1, 2).Time).To(Equal(0.0))
+	c.SetShutter(0.5, 0.5)
+	g.Expect(c.RayForPixel(1, 2).Time).To(Equal(0.5))
+
+	// closing can't happen before opening
+	c.SetShutter(0.5, 0.2)
+	g.Expect(c.shutterClose).To(Equal(0.5))
+
+	c.SetShutter(0.2, 0.6)
+	c.SetSampling(16, JitteredSampling)
+	times := map[float64]bool{}
+	for _, sample := range c.pixelSamples(1, 2) {
+		g.Expect(sample.time).To(BeNumerically(">=", 0.2))
+		g.Expect(sample.time).To(BeNumerically("<", 0.6))
+		g.Expect(c.rayForPixelSample(1, 2, sample).Time).To(Equal(sample.time))
+		times[sample.time] = true
+	}
+	g.Expect(len(times)).To(BeNumerically(">", 1))
+
+	ray := c.RayForPixel(1, 2)
+	g.Expect(ray.Time).To(BeNumerically(">=", 0.2))
+	g.Expect(ray.Time).To(BeNumerically("<", 0.6))
+	g.Expect(c.RayForPixel(1, 2)).To(Equal(ray))
+}
+
+func TestPassSample(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	c := NewCamera(10, 10, math.Pi/2)
+	c.SetShutter(0.2, 0.6)
+
+	// first pass is through the center
+	first := c.passSample(1, 2, 0)
+	g.Expect(first.x).To(Equal(0.5))
+	g.Expect(first.y).To(Equal(0.5))
+
+	// later passes are random, but repeatable
+	seen := map[pixelSample]bool{first: true}
+	for pass := 1; pass < 10; pass++ {
+		sample := c.passSample(1, 2, pass)
+		g.Expect(c.passSample(1, 2, pass)).To(Equal(sample))
+		g.Expect(seen).ToNot(HaveKey(sample))
+		seen[sample] = true
+
+		g.Expect(sample.x).To(BeNumerically(">=", 0))
+		g.Expect(sample.x).To(BeNumerically("<", 1))
+		g.Expect(sample.y).To(BeNumerically(">=", 0))
+		g.Expect(sample.y).To(BeNumerically("<", 1))
+		g.Expect(sample.time).To(BeNumerically(">=", 0.2))
+		g.Expect(sample.time).To(BeNumerically("<", 0.6))
+	}
+	g.Expect(c.passSample(2, 1, 1)).ToNot(Equal(c.passSample(1, 2, 1)))
+}
@@ -0,0 +1,35 @@
+package scene
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+)
+
+// DirectionalLight is a light infinitely far away (such as the sun), so that all of its
+// rays are parallel and travel in the same direction.
+type DirectionalLight struct {
+	*baseLight
+	direction *base.Tuple
+}
+
+// NewDirectionalLight returns a new DirectionalLight object, shining in the supplied direction.
+func NewDirectionalLight(direction *base.Tuple, intensity *image.Color) *DirectionalLight {
+	return &DirectionalLight{
+		baseLight: newBaseLight(intensity),
+		direction: direction.Normalize(),
+	}
+}
+
+// returns the single sample from the point towards the light, which has no end.
+func (l *DirectionalLight) samplesFrom(_ *base.Tuple, _ *rand.Rand) []lightSample {
+	return []lightSample{{direction: l.direction.Negate(), distance: math.Inf(1)}}
+}
+
+// returns the fraction of each color of the light that reaches the point at the time, through
+// any shadows.
+func (l *DirectionalLight) intensityAt(point *base.Tuple, time float64, w *World, samples []lightSample) *image.Color {
+	return w.shadowAt(point, time, samples[0])
+}
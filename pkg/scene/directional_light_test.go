@@ -0,0 +1,90 @@
+package scene
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/object"
+)
+
+func TestNewDirectionalLight(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	l := NewDirectionalLight(base.NewVector(0, -5, 0), image.White)
+	g.Expect(l.direction).To(Equal(base.NewVector(0, -1, 0)))
+	g.Expect(l.GetIntensity()).To(Equal(image.White))
+
+	// every point has the same sample
+	expSample := []lightSample{{direction: base.NewVector(0, 1, 0), distance: math.Inf(1)}}
+	g.Expect(l.samplesFrom(base.Origin, testRand())).To(Equal(expSample))
+	g.Expect(l.samplesFrom(base.NewPoint(100, -20, 3), testRand())).To(Equal(expSample))
+}
+
+func TestDirectionalLightIntensityAt(t *testing.T) {
+	t.Parallel()
+	worldTestSetup()
+
+	tests := []struct {
+		name         string
+		point        *base.Tuple
+		expIntensity float64
+	}{
+		{
+			name:         "nothing between point and light",
+			point:        base.NewPoint(0, 1.0001, 0),
+			expIntensity: 1,
+		},
+		{
+			name:         "object between point and light",
+			point:        base.NewPoint(0, -1.0001, 0),
+			expIntensity: 0,
+		},
+		{
+			name:         "object far away between point and light",
+			point:        base.NewPoint(0, -1000, 0),
+			expIntensity: 0,
+		},
+	}
+
+	w := NewWorld(testLights, testObjects)
+	l := NewDirectionalLight(base.NewVector(0, -1, 0), image.White)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			g.Expect(reaching(l, test.point, w)).To(Equal(gray(test.expIntensity)))
+		})
+	}
+
+	// object that doesn't cast a shadow
+	g := NewWithT(t)
+	s := object.NewSphere()
+	s.Shadow = false
+	noShadowWorld := NewWorld(testLights, []object.Object{s})
+	g.Expect(reaching(l, base.NewPoint(0, -1000, 0), noShadowWorld)).To(Equal(image.White))
+}
+
+func TestLighting_DirectionalLight(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	s := object.NewSphere()
+	m := object.DefaultMaterial
+	eyev := base.NewVector(0, 0, -1)
+	normalv := base.NewVector(0, 0, -1)
+
+	// same as a point light directly in front of the surface
+	l := NewDirectionalLight(base.NewVector(0, 0, 1), image.White)
+	result := lighting(l, s, &m, base.Origin, eyev, normalv, 0, l.samplesFrom(base.Origin, testRand()), image.White)
+	g.Expect(result).To(Equal(image.NewColor(1.9000000000000001, 1.9000000000000001, 1.9000000000000001)))
+
+	// light doesn't change with distance from the surface
+	result = lighting(l, s, &m, base.NewPoint(0, 0, 1000), eyev, normalv, 0, l.samplesFrom(base.NewPoint(0, 0, 1000), testRand()), image.White)
+	g.Expect(result).To(Equal(image.NewColor(1.9000000000000001, 1.9000000000000001, 1.9000000000000001)))
+}
@@ -0,0 +1,80 @@
+package scene
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/object"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/ray"
+)
+
+// The fraction of light passing through fog that is too small to be seen, which limits how far
+// light is gathered along a ray that misses every object.
+const minTransmittance = 0.001
+
+// returns the light that the medium the ray travels through adds along the ray, up to the value
+// (t) of the ray's next hit, and the fraction of the light from the hit that passes through the
+// medium. The medium is the volume inside the object (if the ray is inside one with a volume),
+// or the world's fog otherwise.
+func (w *World) mediumAlong(r *ray.Ray, value float64, inside object.Object) (*image.Color, float64) {
+	density, color := w.fogDensity, w.fogColor
+	scattering := w.fogScattering > 0
+	if inside != nil {
+		volume := inside.GetMaterial().Volume
+		if volume == nil {
+			return image.Black, 1
+		}
+		density, color, scattering = volume.Density, volume.Color, false
+	}
+	if density <= 0 {
+		return image.Black, 1
+	}
+
+	distance := value * r.Direction.Magnitude()
+	transmittance := math.Exp(-density * distance)
+	if !scattering {
+		// the medium is lit evenly, and fades to its own color
+		return color.Multiply(1 - transmittance), transmittance
+	}
+
+	return w.scatteredLight(r, min(distance, -math.Log(minTransmittance)/density)), transmittance
+}
+
+// returns the light from the world's lights that the fog scatters back along the ray, over the
+// distance from the ray's origin. The light is gathered at evenly spaced points along the ray,
+// where light that reaches a point (and isn't blocked by an object) is scattered by the fog's
+// density and color, and fades through the fog between the point and the ray's origin.
+// This shows shafts of light, and the shadows cast through the fog.
+func (w *World) scatteredLight(r *ray.Ray, distance float64) *image.Color {
+	direction := r.Direction.Normalize()
+	step := distance / float64(w.fogScattering)
+	// the points are shifted by an amount seeded by the ray, which turns the bands that evenly
+	// spaced points leave into noise that more samples per pixel smooth out
+	rng := rand.New(rand.NewPCG(
+		math.Float64bits(direction.GetX())^math.Float64bits(direction.GetY())<<1,
+		math.Float64bits(direction.GetZ())^math.Float64bits(r.Origin.GetX()),
+	))
+	offset := rng.Float64()
+
+	sum := image.Black
+	for i := range w.fogScattering {
+		along := (float64(i) + offset) * step
+		point := r.Origin.Add(direction.Multiply(along))
+		for _, light := range w.lights {
+			samples := light.samplesFrom(point, rng)
+			if len(samples) == 0 {
+				continue
+			}
+			attenuation := 0.0
+			for _, sample := range samples {
+				attenuation += light.attenuationAt(sample.distance)
+			}
+			attenuation /= float64(len(samples))
+			reached := light.GetIntensity().MultiplyColor(light.intensityAt(point, r.Time, w, samples)).Multiply(attenuation)
+			sum = sum.Add(reached.Multiply(math.Exp(-w.fogDensity * along)))
+		}
+	}
+
+	return sum.MultiplyColor(w.fogColor).Multiply(w.fogDensity * step)
+}
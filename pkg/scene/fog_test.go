@@ -0,0 +1,187 @@
+package scene
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/object"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/ray"
+)
+
+func TestMediumAlong(t *testing.T) {
+	t.Parallel()
+
+	gray := image.NewColor(0.5, 0.5, 0.5)
+	tests := []struct {
+		name             string
+		fogDensity       float64
+		volume           *object.Volume
+		inside           bool
+		value            float64
+		expAdded         *image.Color
+		expTransmittance float64
+	}{
+		{
+			name:             "no fog",
+			value:            1,
+			expAdded:         image.Black,
+			expTransmittance: 1,
+		},
+		{
+			name:             "fog",
+			fogDensity:       0.5,
+			value:            1,
+			expAdded:         gray.Multiply(1 - math.Exp(-1)),
+			expTransmittance: math.Exp(-1),
+		},
+		{
+			name:             "fog without a hit",
+			fogDensity:       0.5,
+			value:            math.Inf(1),
+			expAdded:         gray,
+			expTransmittance: 0,
+		},
+		{
+			name:             "inside an object without a volume",
+			fogDensity:       0.5,
+			inside:           true,
+			value:            1,
+			expAdded:         image.Black,
+			expTransmittance: 1,
+		},
+		{
+			name:             "inside an object with a volume",
+			fogDensity:       0.5,
+			volume:           &object.Volume{Density: 1, Color: image.NewColor(1, 0, 0)},
+			inside:           true,
+			value:            1,
+			expAdded:         image.NewColor(1-math.Exp(-2), 0, 0),
+			expTransmittance: math.Exp(-2),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			w := NewWorld(nil, nil)
+			w.SetFog(test.fogDensity, gray)
+			var inside object.Object
+			if test.inside {
+				inside = object.NewSphere()
+				inside.GetMaterial().Volume = test.volume
+			}
+
+			// the ray's direction is 2 units long
+			r := ray.NewRay(base.Origin, base.NewVector(0, 0, 2))
+			added, transmittance := w.mediumAlong(r, test.value, inside)
+			g.Expect(added.Equals(test.expAdded)).To(BeTrue())
+			g.Expect(transmittance).To(BeNumerically("~", test.expTransmittance, base.Epsilon))
+		})
+	}
+}
+
+func TestScatteredLight(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	light := NewPointLight(base.NewPoint(0, 5, 0), image.White)
+	w := NewWorld([]Light{light}, nil)
+	w.SetFog(0.1, image.NewColor(1, 0.5, 0.5))
+	r := ray.NewRay(base.NewPoint(-1, 0, 0), base.NewVector(1, 0, 0))
+
+	// the fog is lit evenly without scattering
+	added, _ := w.mediumAlong(r, 2, nil)
+	g.Expect(added.Equals(image.NewColor(1, 0.5, 0.5).Multiply(1 - math.Exp(-0.2)))).To(BeTrue())
+
+	// the light scattered by the fog is tinted by its color
+	w.SetFogScattering(8)
+	added, transmittance := w.mediumAlong(r, 2, nil)
+	g.Expect(transmittance).To(BeNumerically("~", math.Exp(-0.2), base.Epsilon))
+	g.Expect(added).To(Equal(w.scatteredLight(r, 2)))
+	red, green, blue := added.RGB()
+	g.Expect(red).To(BeNumerically(">", 0))
+	g.Expect(green).To(BeNumerically("~", red/2, base.Epsilon))
+	g.Expect(blue).To(Equal(green))
+	// each point along the ray only sees the light through less than the whole density
+	g.Expect(red).To(BeNumerically("<", 0.2))
+
+	// more of the light is gathered along a longer ray, up to where the fog hides it
+	g.Expect(w.scatteredLight(r, 4).Luminance()).To(BeNumerically(">", added.Luminance()))
+	infinite, _ := w.mediumAlong(r, math.Inf(1), nil)
+	g.Expect(infinite).To(Equal(w.scatteredLight(r, -math.Log(minTransmittance)/0.1)))
+
+	// the fog in the shadow of an object isn't lit
+	roof := object.NewPlane()
+	roof.SetTransform(base.Translate(0, 1, 0))
+	w.objects = []object.Object{roof}
+	g.Expect(w.scatteredLight(r, 2)).To(Equal(image.Black))
+
+	w.SetFogScattering(-1)
+	added, _ = w.mediumAlong(r, 2, nil)
+	g.Expect(added.Equals(image.NewColor(1, 0.5, 0.5).Multiply(1 - math.Exp(-0.2)))).To(BeTrue())
+}
+
+func TestColorAt_Fog(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	worldTestSetup()
+
+	w := NewWorld(testLights, testObjects)
+	hit := ray.NewRay(base.NewPoint(0, 0, -5), base.NewVector(0, 0, 1))
+	clear := w.ColorAt(hit, remainingReflections)
+	w.SetBackground(NewSolidBackground(image.White), false)
+
+	// the sphere's color fades into the fog over the 4 units to its surface
+	fog := image.NewColor(0.2, 0.3, 0.4)
+	w.SetFog(0.25, fog)
+	expected := clear.Multiply(math.Exp(-1)).Add(fog.Multiply(1 - math.Exp(-1)))
+	g.Expect(w.ColorAt(hit, remainingReflections).Equals(expected)).To(BeTrue())
+
+	// a ray that misses sees only the fog
+	miss := ray.NewRay(base.NewPoint(0, 0, -5), base.NewVector(0, 1, 0))
+	g.Expect(w.ColorAt(miss, remainingReflections)).To(Equal(fog))
+
+	w.SetFog(0, fog)
+	g.Expect(w.ColorAt(hit, remainingReflections)).To(Equal(clear))
+	g.Expect(w.ColorAt(miss, remainingReflections)).To(Equal(image.White))
+}
+
+func TestColorAt_Volume(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// a clear glass ball in front of a white wall
+	glass := object.GlassSphere()
+	glass.Ambient = 0
+	glass.Diffuse = 0
+	glass.Specular = 0
+	glass.Reflective = 0
+	glass.RefractiveIndex = 1
+	wall := object.NewPlane()
+	wall.Ambient = 1
+	wall.Diffuse = 0
+	wall.Specular = 0
+	wall.SetTransform(base.Translate(0, 0, 5).Multiply(base.RotateX(math.Pi / 2)))
+	light := NewPointLight(base.NewPoint(0, 0, -10), image.White)
+	w := NewWorld([]Light{light}, []object.Object{glass, wall})
+	r := ray.NewRay(base.NewPoint(0, 0, -5), base.NewVector(0, 0, 1))
+	g.Expect(w.ColorAt(r, remainingReflections).Equals(image.White)).To(BeTrue())
+
+	// smoke inside the ball fades the wall behind it over the 2 units through the ball
+	glass.Volume = &object.Volume{Density: 0.5, Color: image.NewColor(0.2, 0.2, 0.2)}
+	expected := image.White.Multiply(math.Exp(-1)).Add(image.NewColor(0.2, 0.2, 0.2).Multiply(1 - math.Exp(-1)))
+	g.Expect(w.ColorAt(r, remainingReflections).Equals(expected)).To(BeTrue())
+
+	// the fog outside the ball fades the wall before it reaches the ball, but doesn't fill the ball
+	w.SetFog(0.1, image.Black)
+	behind := image.White.Multiply(math.Exp(-0.4))
+	expected = behind.Multiply(math.Exp(-1)).Add(image.NewColor(0.2, 0.2, 0.2).Multiply(1 - math.Exp(-1)))
+	expected = expected.Multiply(math.Exp(-0.4))
+	g.Expect(w.ColorAt(r, remainingReflections).Equals(expected)).To(BeTrue())
+}
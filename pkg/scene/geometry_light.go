@@ -0,0 +1,77 @@
+package scene
+
+import (
+	"math/rand/v2"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/object"
+)
+
+// GeometryLight is an emissive object that lights the other objects in the scene, like an AreaLight
+// in the shape of the object. The light is the object's emission, given off from sample points spread
+// over its surface, each of which only lights the side of the surface that it faces.
+type GeometryLight struct {
+	*baseLight
+	object object.Object
+	points []object.SurfacePoint
+}
+
+// NewGeometryLight returns a new GeometryLight for the object, with the number of sample points on its
+// surface. The points are picked once, so shadows from the light are smooth and repeatable.
+func NewGeometryLight(obj object.Object, samples int) *GeometryLight {
+	intensity := image.Black
+	if obj.GetMaterial().Emission != nil {
+		intensity = obj.GetMaterial().Emission
+	}
+	points := object.SurfacePoints(obj, samples, rand.New(rand.NewPCG(uint64(samples), 0)))
+	for i, p := range points {
+		// lift the points off the surface, so the object doesn't shadow them
+		points[i].Point = p.Point.Add(p.Normal.Multiply(base.Epsilon * 2))
+	}
+
+	return &GeometryLight{
+		baseLight: newBaseLight(intensity),
+		object:    obj,
+		points:    points,
+	}
+}
+
+// returns whether the object is the light's object, or part of it.
+func (l *GeometryLight) contains(obj object.Object) bool {
+	for ; obj != nil; obj = obj.GetParent() {
+		if obj == l.object {
+			return true
+		}
+	}
+
+	return false
+}
+
+// returns a sample from the point to each sample point on the light's surface.
+func (l *GeometryLight) samplesFrom(point *base.Tuple, _ *rand.Rand) []lightSample {
+	samples := make([]lightSample, 0, len(l.points))
+	for _, p := range l.points {
+		samples = append(samples, newLightSample(p.Point, point))
+	}
+
+	return samples
+}
+
+// returns the average fraction of each color of the light's samples that reaches the point at
+// the time, through any shadows. Samples on the side of the surface facing away from the point
+// don't reach it. The samples are in the same order as the light's points.
+func (l *GeometryLight) intensityAt(point *base.Tuple, time float64, w *World, samples []lightSample) *image.Color {
+	if len(l.points) == 0 {
+		return image.Black
+	}
+	total := image.Black
+	for i, p := range l.points {
+		if point.Subtract(p.Point).DotProduct(p.Normal) <= 0 {
+			continue
+		}
+		total = total.Add(w.shadowAt(point, time, samples[i].from(point)))
+	}
+
+	return total.Multiply(1 / float64(len(l.points)))
+}
@@ -0,0 +1,175 @@
+package scene
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/object"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/ray"
+)
+
+// returns a sphere that only glows with the color.
+func glowingSphere(emission *image.Color) *object.Sphere {
+	s := object.NewSphere()
+	s.Ambient = 0
+	s.Diffuse = 0
+	s.Specular = 0
+	s.Emission = emission
+
+	return s
+}
+
+func TestNewGeometryLight(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	s := glowingSphere(image.NewColor(1, 0.5, 0))
+	grp := object.NewGroup()
+	grp.SetTransform(base.Translate(0, 3, 0))
+	grp.Add(s)
+
+	l := NewGeometryLight(s, 16)
+	g.Expect(l.GetIntensity()).To(Equal(image.NewColor(1, 0.5, 0)))
+	g.Expect(l.points).To(HaveLen(16))
+	for _, p := range l.points {
+		// the points are just off the surface
+		g.Expect(p.Point.Subtract(base.NewPoint(0, 3, 0)).Magnitude()).To(BeNumerically("~", 1+2*base.Epsilon, base.Epsilon))
+	}
+	g.Expect(l.contains(s)).To(BeTrue())
+	g.Expect(l.contains(grp)).To(BeFalse())
+	g.Expect(l.contains(object.NewSphere())).To(BeFalse())
+
+	// the light is repeatable
+	g.Expect(NewGeometryLight(s, 16).points).To(Equal(l.points))
+
+	// a glowing group lights the scene from all of its children
+	grp.SetMaterial(s.GetMaterial())
+	l = NewGeometryLight(grp, 16)
+	g.Expect(l.GetIntensity()).To(Equal(image.NewColor(1, 0.5, 0)))
+	g.Expect(l.contains(s)).To(BeTrue())
+
+	// an object that doesn't glow gives off no light
+	g.Expect(NewGeometryLight(object.NewCube(), 4).GetIntensity()).To(Equal(image.Black))
+}
+
+func TestGeometryLightIntensityAt(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	s := glowingSphere(image.White)
+	l := NewGeometryLight(s, 64)
+	w := NewWorld([]Light{l}, []object.Object{s})
+
+	// only the near side of the sphere lights the point, and the sphere doesn't shadow it
+	point := base.NewPoint(0, 0, -5)
+	var facing float64
+	for _, p := range l.points {
+		if point.Subtract(p.Point).DotProduct(p.Normal) > 0 {
+			facing++
+		}
+	}
+	intensity := reaching(l, point, w)
+	g.Expect(intensity.Equals(image.White.Multiply(facing / 64))).To(BeTrue())
+	g.Expect(intensity.Luminance()).To(BeNumerically("~", 0.4, 0.1))
+
+	// an object in the way blocks the light
+	wall := object.NewCube()
+	wall.SetTransform(base.Translate(0, 0, -3), base.Scale(3, 3, 0.1))
+	w.objects = append(w.objects, wall)
+	g.Expect(reaching(l, point, w)).To(Equal(image.Black))
+
+	// a light without any points gives off no light
+	g.Expect(reaching(NewGeometryLight(object.NewPlane(), 4), point, w)).To(Equal(image.Black))
+}
+
+func TestColorAt_Emission(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// the glow is added to the lit surface
+	s := object.NewSphere()
+	r := ray.NewRay(base.NewPoint(0, 0, -5), base.NewVector(0, 0, 1))
+	w := NewWorld([]Light{NewPointLight(base.NewPoint(-10, 10, -10), image.White)}, []object.Object{s})
+	lit := w.ColorAt(r, remainingReflections)
+	s.Emission = image.NewColor(0.2, 0, 0)
+	g.Expect(w.ColorAt(r, remainingReflections).Equals(lit.Add(image.NewColor(0.2, 0, 0)))).To(BeTrue())
+
+	// the glow is seen without any lights
+	w = NewWorld(nil, []object.Object{s})
+	g.Expect(w.ColorAt(r, remainingReflections)).To(Equal(image.NewColor(0.2, 0, 0)))
+}
+
+func TestPathColorAt_GeometryLight(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	floor := object.NewPlane()
+	bulb := glowingSphere(image.NewColor(2, 2, 2))
+	bulb.SetTransform(base.Translate(0, 2, 0))
+	w := NewWorld(nil, []object.Object{floor, bulb})
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	// the glow is seen directly
+	r := ray.NewRay(base.NewPoint(0, 2, -5), base.NewVector(0, 0, 1))
+	g.Expect(w.pathColorAt(r, rng)).To(Equal(image.NewColor(2, 2, 2)))
+
+	// without the light, the floor is only lit by paths that bounce into the glow
+	down := ray.NewRay(base.NewPoint(3, 1, 0), base.NewVector(0, -1, 0))
+	colors := map[image.Color]bool{}
+	for range 50 {
+		colors[*w.pathColorAt(down, rng)] = true
+	}
+	g.Expect(colors).To(HaveKey(*image.Black))
+	g.Expect(len(colors)).To(BeNumerically(">", 1))
+
+	// with the light, the floor is lit directly, and the paths that bounce into the glow don't count it again
+	w.lights = []Light{NewGeometryLight(bulb, 32)}
+	g.Expect(w.isLight(bulb)).To(BeTrue())
+	g.Expect(w.isLight(floor)).To(BeFalse())
+	direct := w.pathColorAt(down, rng)
+	g.Expect(direct.Luminance()).To(BeNumerically(">", 0))
+	for range 50 {
+		g.Expect(w.pathColorAt(down, rng)).To(Equal(direct))
+	}
+}
+
+func TestPathColorAt_GeometryLightReflections(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	bulb := glowingSphere(image.NewColor(2, 2, 2))
+	bulb.SetTransform(base.Translate(0, 2, 0))
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	// a glossy floor's highlight from the light is in its direct light, so the paths that reflect
+	// off its facets into the glow don't count it again
+	glossy := object.NewPlane()
+	m := microfacetMaterial(image.White, 1, 0.3)
+	glossy.SetMaterial(&m)
+	w := NewWorld(nil, []object.Object{glossy, bulb})
+	down := ray.NewRay(base.NewPoint(0, 4, -4), base.NewVector(0, -4, 2).Normalize())
+	colors := map[image.Color]bool{}
+	for range 50 {
+		colors[*w.pathColorAt(down, rng)] = true
+	}
+	g.Expect(len(colors)).To(BeNumerically(">", 1))
+
+	w.lights = []Light{NewGeometryLight(bulb, 32)}
+	direct := w.pathColorAt(down, rng)
+	g.Expect(direct.Luminance()).To(BeNumerically(">", 0))
+	for range 50 {
+		g.Expect(w.pathColorAt(down, rng)).To(Equal(direct))
+	}
+
+	// a mirror has no highlight, so the glow is seen in it
+	mirror := object.NewPlane()
+	mirror.Diffuse = 0
+	mirror.Specular = 0
+	mirror.Reflective = 1
+	w = NewWorld([]Light{NewGeometryLight(bulb, 32)}, []object.Object{mirror, bulb})
+	g.Expect(w.pathColorAt(down, rng)).To(Equal(image.NewColor(2, 2, 2)))
+}
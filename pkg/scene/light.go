@@ -2,69 +2,191 @@ package scene
 
 import (
 	"math"
+	"math/rand/v2"
 
 	"github.com/sjberman/golang-ray-tracer/pkg/base"
 	"github.com/sjberman/golang-ray-tracer/pkg/image"
 	"github.com/sjberman/golang-ray-tracer/pkg/scene/object"
 )
 
+// Light is a source of light in a scene. The samples of a light are taken once for each point that
+// it lights (using the random number generator for any random placement), and the same samples are
+// used for both the point's shadows and its shading.
+type Light interface {
+	GetIntensity() *image.Color
+	SetAttenuation(Attenuation)
+	samplesFrom(*base.Tuple, *rand.Rand) []lightSample
+	intensityAt(*base.Tuple, float64, *World, []lightSample) *image.Color
+	attenuationAt(float64) float64
+}
+
+// Attenuation determines how the intensity of a light falls off with distance, where
+// the light is scaled by 1 / (Constant + Linear*distance + Quadratic*distance^2).
+type Attenuation struct {
+	Constant  float64
+	Linear    float64
+	Quadratic float64
+}
+
+// NoAttenuation keeps the light at full intensity at any distance.
+var NoAttenuation = Attenuation{Constant: 1}
+
+// InverseSquareAttenuation is the physically based falloff of light.
+var InverseSquareAttenuation = Attenuation{Quadratic: 1}
+
+// lightSample is the direction and distance from a point to a sample of a light.
+type lightSample struct {
+	direction *base.Tuple
+	distance  float64
+	position  *base.Tuple // nil for a light infinitely far away
+}
+
+// returns the lightSample from the point to a light at the position.
+func newLightSample(position, point *base.Tuple) lightSample {
+	v := position.Subtract(point)
+
+	return lightSample{
+		direction: v.Normalize(),
+		distance:  v.Magnitude(),
+		position:  position,
+	}
+}
+
+// returns the same sample of the light seen from another point, such as the point just above the
+// surface that shadows are found from.
+func (s lightSample) from(point *base.Tuple) lightSample {
+	if s.position == nil {
+		return s
+	}
+
+	return newLightSample(s.position, point)
+}
+
+// baseLight is the base implementation of a Light.
+type baseLight struct {
+	intensity   *image.Color
+	attenuation Attenuation
+}
+
+// returns a new baseLight with no attenuation.
+func newBaseLight(intensity *image.Color) *baseLight {
+	return &baseLight{
+		intensity:   intensity,
+		attenuation: NoAttenuation,
+	}
+}
+
+// GetIntensity returns the color of the light.
+func (l *baseLight) GetIntensity() *image.Color {
+	return l.intensity
+}
+
+// SetAttenuation sets how the intensity of the light falls off with distance.
+func (l *baseLight) SetAttenuation(attenuation Attenuation) {
+	l.attenuation = attenuation
+}
+
+// returns the factor the light is scaled by at the distance from the light.
+func (l *baseLight) attenuationAt(distance float64) float64 {
+	if math.IsInf(distance, 1) {
+		// a light infinitely far away is not attenuated
+		return 1
+	}
+	a := l.attenuation
+	denominator := a.Constant + a.Linear*distance + a.Quadratic*distance*distance
+	if denominator <= 0 {
+		return 1
+	}
+
+	return 1 / denominator
+}
+
 // PointLight is a light with no size, existing at a single point.
 type PointLight struct {
-	position  *base.Tuple
-	intensity *image.Color
+	*baseLight
+	position *base.Tuple
 }
 
 // NewPointLight returns a new PointLight object.
 func NewPointLight(pos *base.Tuple, intensity *image.Color) *PointLight {
 	return &PointLight{
+		baseLight: newBaseLight(intensity),
 		position:  pos,
-		intensity: intensity,
 	}
 }
 
+// returns the single sample from the point to the position of the light.
+func (l *PointLight) samplesFrom(point *base.Tuple, _ *rand.Rand) []lightSample {
+	return []lightSample{newLightSample(l.position, point)}
+}
+
+// returns the fraction of each color of the light that reaches the point at the time, through
+// any shadows.
+func (l *PointLight) intensityAt(point *base.Tuple, time float64, w *World, samples []lightSample) *image.Color {
+	return w.shadowAt(point, time, samples[0].from(point))
+}
+
 // lighting returns the color at a point based on the light, material, and the eye/normal vectors.
+// The time is when the ray hit the point, the samples are the light's samples from the point, and
+// the intensity is the fraction (from 0 to 1) of each color of the light that reaches the point.
+// This is the Phong shader's lighting model.
 func lighting(
-	light *PointLight,
+	light Light,
 	obj object.Object,
 	material *object.Material,
 	point, eyev, normalv *base.Tuple,
-	inShadow bool,
+	time float64,
+	samples []lightSample,
+	intensity *image.Color,
 ) *image.Color {
-	color := material.Color
-	if material.Pattern != nil {
-		color = obj.PatternAt(point, material.Pattern)
-	}
-	diffuse, specular := image.Black, image.Black
 	// combine surface color with light's color
-	effectiveColor := color.MultiplyColor(light.intensity)
-
-	// find the direction to the light source
-	lightv := light.position.Subtract(point).Normalize()
+	effectiveColor := surfaceColor(obj, material, point, time).MultiplyColor(light.GetIntensity())
 
 	// compute the ambient contribution
 	ambient := effectiveColor.Multiply(material.Ambient)
-	if inShadow {
+	if intensity.Equals(image.Black) {
 		return ambient
 	}
 
-	// lightDotNormal represents the cosine of the angle between the light vector
-	// and the normal vector. A negative number means the light is on the
-	// other side of the surface.
-	lightDotNormal := lightv.DotProduct(normalv)
-	if lightDotNormal >= 0 {
-		// compute the diffuse contribution
-		diffuse = effectiveColor.Multiply(material.Diffuse).Multiply(lightDotNormal)
-
-		// reflectDotEye represents the cosine of the angle between the reflection vector
-		// and the eye vector. A negative number means the light reflects away from the eye.
-		reflectv := lightv.Negate().Reflect(normalv)
-		reflectDotEye := reflectv.DotProduct(eyev)
-		if reflectDotEye > 0 {
-			// compute the specular contribution
-			factor := math.Pow(reflectDotEye, material.Shininess)
-			specular = light.intensity.Multiply(material.Specular).Multiply(factor)
+	// average the diffuse and specular contributions over each sample of the light
+	sum := image.Black
+	for _, sample := range samples {
+		diffuse, specular := image.Black, image.Black
+
+		// the direction to the light source
+		lightv := sample.direction
+
+		// lightDotNormal represents the cosine of the angle between the light vector
+		// and the normal vector. A negative number means the light is on the
+		// other side of the surface.
+		lightDotNormal := lightv.DotProduct(normalv)
+		if lightDotNormal >= 0 {
+			// compute the diffuse contribution
+			diffuse = effectiveColor.Multiply(material.Diffuse).Multiply(lightDotNormal)
+
+			// reflectDotEye represents the cosine of the angle between the reflection vector
+			// and the eye vector. A negative number means the light reflects away from the eye.
+			reflectv := lightv.Negate().Reflect(normalv)
+			reflectDotEye := reflectv.DotProduct(eyev)
+			if reflectDotEye > 0 {
+				// compute the specular contribution
+				factor := math.Pow(reflectDotEye, material.Shininess)
+				specular = light.GetIntensity().Multiply(material.Specular).Multiply(factor)
+			}
 		}
+		// scale the light by its falloff over the distance to the point
+		sum = sum.Add(diffuse.Add(specular).Multiply(light.attenuationAt(sample.distance)))
 	}
+
 	// Add the three contributions together to get the final shading
-	return ambient.Add(diffuse.Add(specular))
+	return ambient.Add(sum.MultiplyColor(intensity).Multiply(1 / float64(len(samples))))
+}
+
+// returns the color of the material at a point on the object, from its pattern if it has one.
+func surfaceColor(obj object.Object, material *object.Material, point *base.Tuple, time float64) *image.Color {
+	if material.Pattern != nil {
+		return obj.PatternAt(point, time, material.Pattern)
+	}
+
+	return material.Color
 }
@@ -2,6 +2,7 @@ package scene
 
 import (
 	"math"
+	"math/rand/v2"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -20,6 +21,79 @@ func TestNewPointLight(t *testing.T) {
 	p := NewPointLight(point, color)
 	g.Expect(p.position).To(Equal(point))
 	g.Expect(p.intensity).To(Equal(color))
+	g.Expect(p.attenuation).To(Equal(NoAttenuation))
+
+	p.SetAttenuation(InverseSquareAttenuation)
+	g.Expect(p.attenuation).To(Equal(InverseSquareAttenuation))
+}
+
+func TestAttenuationAt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		attenuation Attenuation
+		distance    float64
+		expFactor   float64
+	}{
+		{
+			name:        "no attenuation",
+			attenuation: NoAttenuation,
+			distance:    100,
+			expFactor:   1,
+		},
+		{
+			name:        "inverse square",
+			attenuation: InverseSquareAttenuation,
+			distance:    4,
+			expFactor:   0.0625,
+		},
+		{
+			name:        "constant, linear, and quadratic",
+			attenuation: Attenuation{Constant: 1, Linear: 0.5, Quadratic: 0.25},
+			distance:    2,
+			expFactor:   1.0 / 3,
+		},
+		{
+			name:        "infinite distance",
+			attenuation: InverseSquareAttenuation,
+			distance:    math.Inf(1),
+			expFactor:   1,
+		},
+		{
+			name:        "zero distance",
+			attenuation: InverseSquareAttenuation,
+			distance:    0,
+			expFactor:   1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			l := NewPointLight(base.Origin, image.White)
+			l.SetAttenuation(test.attenuation)
+			g.Expect(l.attenuationAt(test.distance)).To(Equal(test.expFactor))
+		})
+	}
+}
+
+// returns a gray color with every value set to v.
+func gray(v float64) *image.Color {
+	return image.NewColor(v, v, v)
+}
+
+// returns a random number generator with a fixed seed.
+func testRand() *rand.Rand {
+	return rand.New(rand.NewPCG(1, 2))
+}
+
+// returns the fraction of each color of the light that reaches the point at time 0, from a new
+// set of the light's samples.
+func reaching(light Light, point *base.Tuple, w *World) *image.Color {
+	return light.intensityAt(point, 0, w, light.samplesFrom(point, testRand()))
 }
 
 func TestLighting(t *testing.T) {
@@ -30,48 +104,60 @@ func TestLighting(t *testing.T) {
 	position := base.Origin
 
 	tests := []struct {
-		name     string
-		eyev     *base.Tuple
-		light    *PointLight
-		inShadow bool
-		expColor *image.Color
+		name      string
+		eyev      *base.Tuple
+		light     *PointLight
+		intensity float64
+		expColor  *image.Color
 	}{
 		{
-			name:     "lighting with eye between light and surface",
-			eyev:     base.NewVector(0, 0, -1),
-			light:    NewPointLight(base.NewPoint(0, 0, -10), image.White),
-			expColor: image.NewColor(1.9000000000000001, 1.9000000000000001, 1.9000000000000001),
+			name:      "lighting with eye between light and surface",
+			eyev:      base.NewVector(0, 0, -1),
+			light:     NewPointLight(base.NewPoint(0, 0, -10), image.White),
+			intensity: 1,
+			expColor:  image.NewColor(1.9000000000000001, 1.9000000000000001, 1.9000000000000001),
+		},
+		{
+			name:      "lighting with surface in shadow",
+			eyev:      base.NewVector(0, 0, -1),
+			light:     NewPointLight(base.NewPoint(0, 0, -10), image.White),
+			intensity: 0,
+			expColor:  image.NewColor(0.1, 0.1, 0.1),
 		},
 		{
-			name:     "lighting with surface in shadow",
-			eyev:     base.NewVector(0, 0, -1),
-			light:    NewPointLight(base.NewPoint(0, 0, -10), image.White),
-			inShadow: true,
-			expColor: image.NewColor(0.1, 0.1, 0.1),
+			name:      "lighting with surface partially in shadow",
+			eyev:      base.NewVector(0, 0, -1),
+			light:     NewPointLight(base.NewPoint(0, 0, -10), image.White),
+			intensity: 0.5,
+			expColor:  image.NewColor(1.0, 1.0, 1.0),
 		},
 		{
-			name:     "lighting with eye between light and surface, eye offset 45 degrees",
-			eyev:     base.NewVector(0, math.Sqrt(2)/2, -math.Sqrt(2)/2),
-			light:    NewPointLight(base.NewPoint(0, 0, -10), image.White),
-			expColor: image.NewColor(1.0, 1.0, 1.0),
+			name:      "lighting with eye between light and surface, eye offset 45 degrees",
+			eyev:      base.NewVector(0, math.Sqrt(2)/2, -math.Sqrt(2)/2),
+			light:     NewPointLight(base.NewPoint(0, 0, -10), image.White),
+			intensity: 1,
+			expColor:  image.NewColor(1.0, 1.0, 1.0),
 		},
 		{
-			name:     "lighting with eye opposite surface, light offset 45 degrees",
-			eyev:     base.NewVector(0, 0, -1),
-			light:    NewPointLight(base.NewPoint(0, 10, -10), image.White),
-			expColor: image.NewColor(0.7363961030678927, 0.7363961030678927, 0.7363961030678927),
+			name:      "lighting with eye opposite surface, light offset 45 degrees",
+			eyev:      base.NewVector(0, 0, -1),
+			light:     NewPointLight(base.NewPoint(0, 10, -10), image.White),
+			intensity: 1,
+			expColor:  image.NewColor(0.7363961030678927, 0.7363961030678927, 0.7363961030678927),
 		},
 		{
-			name:     "lighting with eye in the path of the reflection vector",
-			eyev:     base.NewVector(0, -math.Sqrt(2)/2, -math.Sqrt(2)/2),
-			light:    NewPointLight(base.NewPoint(0, 10, -10), image.White),
-			expColor: image.NewColor(1.6363961030678928, 1.6363961030678928, 1.6363961030678928),
+			name:      "lighting with eye in the path of the reflection vector",
+			eyev:      base.NewVector(0, -math.Sqrt(2)/2, -math.Sqrt(2)/2),
+			light:     NewPointLight(base.NewPoint(0, 10, -10), image.White),
+			intensity: 1,
+			expColor:  image.NewColor(1.6363961030678928, 1.6363961030678928, 1.6363961030678928),
 		},
 		{
-			name:     "lighting with light behind the surface",
-			eyev:     base.NewVector(0, 0, -1),
-			light:    NewPointLight(base.NewPoint(0, 0, 10), image.White),
-			expColor: image.NewColor(0.1, 0.1, 0.1),
+			name:      "lighting with light behind the surface",
+			eyev:      base.NewVector(0, 0, -1),
+			light:     NewPointLight(base.NewPoint(0, 0, 10), image.White),
+			intensity: 1,
+			expColor:  image.NewColor(0.1, 0.1, 0.1),
 		},
 	}
 
@@ -81,12 +167,25 @@ func TestLighting(t *testing.T) {
 			t.Parallel()
 			g := NewWithT(t)
 
-			result := lighting(test.light, s, &m, position, test.eyev, normalv, test.inShadow)
+			result := lighting(test.light, s, &m, position, test.eyev, normalv, 0, test.light.samplesFrom(position, testRand()), gray(test.intensity))
 			g.Expect(result).To(Equal(test.expColor))
 		})
 	}
 }
 
+func TestLighting_TintedShadow(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// the light reaching the surface through a colored object is tinted, but the ambient light isn't
+	m := object.DefaultMaterial
+	light := NewPointLight(base.NewPoint(0, 0, -10), image.White)
+	eyev := base.NewVector(0, 0, -1)
+	normalv := base.NewVector(0, 0, -1)
+	result := lighting(light, object.NewSphere(), &m, base.Origin, eyev, normalv, 0, light.samplesFrom(base.Origin, testRand()), image.NewColor(1, 0.5, 0))
+	g.Expect(result.Equals(image.NewColor(1.9, 1.0, 0.1))).To(BeTrue())
+}
+
 func TestLighting_WithPattern(t *testing.T) {
 	t.Parallel()
 	g := NewWithT(t)
@@ -102,8 +201,30 @@ func TestLighting_WithPattern(t *testing.T) {
 	m.Diffuse = 0
 	m.Specular = 0
 	light := NewPointLight(base.NewPoint(0, 0, -10), image.White)
-	c1 := lighting(light, s, &m, base.NewPoint(0.9, 0, 0), eyev, normalv, false)
-	c2 := lighting(light, s, &m, base.NewPoint(1.1, 0, 0), eyev, normalv, false)
+	c1 := lighting(light, s, &m, base.NewPoint(0.9, 0, 0), eyev, normalv, 0, light.samplesFrom(base.NewPoint(0.9, 0, 0), testRand()), image.White)
+	c2 := lighting(light, s, &m, base.NewPoint(1.1, 0, 0), eyev, normalv, 0, light.samplesFrom(base.NewPoint(1.1, 0, 0), testRand()), image.White)
 	g.Expect(c1).To(Equal(image.White))
 	g.Expect(c2).To(Equal(image.Black))
 }
+
+func TestLighting_Attenuation(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	s := object.NewSphere()
+	m := object.DefaultMaterial
+	eyev := base.NewVector(0, 0, -1)
+	normalv := base.NewVector(0, 0, -1)
+
+	// ambient light is not attenuated, but diffuse and specular are
+	light := NewPointLight(base.NewPoint(0, 0, -2), image.White)
+	light.SetAttenuation(InverseSquareAttenuation)
+	result := lighting(light, s, &m, base.Origin, eyev, normalv, 0, light.samplesFrom(base.Origin, testRand()), image.White)
+	g.Expect(result).To(Equal(image.NewColor(0.55, 0.55, 0.55)))
+
+	// further away is darker
+	light = NewPointLight(base.NewPoint(0, 0, -10), image.White)
+	light.SetAttenuation(InverseSquareAttenuation)
+	result = lighting(light, s, &m, base.Origin, eyev, normalv, 0, light.samplesFrom(base.Origin, testRand()), image.White)
+	g.Expect(result).To(Equal(image.NewColor(0.11800000000000001, 0.11800000000000001, 0.11800000000000001)))
+}
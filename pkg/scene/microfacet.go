@@ -0,0 +1,185 @@
+package scene
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/object"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/ray"
+)
+
+const (
+	// The smallest roughness used, since a perfectly smooth surface would reflect a point light
+	// as an infinitely small and bright highlight.
+	minRoughness = 0.03
+	// The fraction of light reflected straight back by a dielectric (non-metal) surface, such as
+	// plastic or paint.
+	dielectricReflectance = 0.04
+)
+
+// returns the color of a point lit by a light using the microfacet model, where the surface is made
+// of tiny mirrors (facets) facing in directions spread by the material's roughness (the GGX
+// distribution), which shadow and mask each other (the Smith function), and which reflect more light
+// at grazing angles (the Fresnel effect). The light that isn't reflected enters the surface and is
+// scattered back out in the surface's color, unless the surface is a metal, which absorbs it but
+// tints its reflections instead. The material's ambient term is kept, as in the Phong model.
+func microfacetLighting(
+	light Light,
+	obj object.Object,
+	material *object.Material,
+	point, eyev, normalv *base.Tuple,
+	time float64,
+	samples []lightSample,
+	intensity *image.Color,
+) *image.Color {
+	baseColor := surfaceColor(obj, material, point, time)
+	ambient := baseColor.MultiplyColor(light.GetIntensity()).Multiply(material.Ambient)
+	if intensity.Equals(image.Black) {
+		return ambient
+	}
+
+	f0 := facingReflectance(baseColor, material.Metallic)
+	alpha := roughnessAlpha(material.Roughness)
+	sum := image.Black
+	for _, sample := range samples {
+		reflected := microfacetReflectance(baseColor, f0, material.Metallic, alpha, normalv, eyev, sample.direction)
+		sum = sum.Add(reflected.Multiply(light.attenuationAt(sample.distance)))
+	}
+	sum = sum.MultiplyColor(light.GetIntensity()).MultiplyColor(intensity)
+
+	return ambient.Add(sum.Multiply(1 / float64(len(samples))))
+}
+
+// returns the fraction of each color of the light arriving from the light vector that the surface
+// reflects towards the eye, including the cosine of the light's angle to the surface.
+func microfacetReflectance(
+	baseColor, f0 *image.Color,
+	metallic, alpha float64,
+	normalv, eyev, lightv *base.Tuple,
+) *image.Color {
+	nDotL := normalv.DotProduct(lightv)
+	nDotV := normalv.DotProduct(eyev)
+	if nDotL <= 0 || nDotV <= 0 {
+		return image.Black
+	}
+	// the facets that reflect the light towards the eye face halfway between them
+	halfv := lightv.Add(eyev).Normalize()
+	nDotH := max(normalv.DotProduct(halfv), 0)
+	vDotH := max(eyev.DotProduct(halfv), 0)
+
+	fresnel := schlickColor(f0, vDotH)
+	specular := fresnel.Multiply(ggxDistribution(nDotH, alpha) * smithMasking(nDotL, nDotV, alpha) / (4 * nDotL * nDotV))
+	diffuse := image.White.Subtract(fresnel).MultiplyColor(baseColor).Multiply((1 - metallic) / math.Pi)
+
+	// a light's intensity is the light falling on a surface facing it, which cancels out the
+	// 1/π that spreads diffuse light over the hemisphere, so that a white light on a white surface
+	// is as bright as in the Phong model
+	return diffuse.Add(specular).Multiply(math.Pi * nDotL)
+}
+
+// returns the reflectance of a surface facing the eye, which is the same for every color of a
+// dielectric, but is the color of a metal.
+func facingReflectance(baseColor *image.Color, metallic float64) *image.Color {
+	dielectric := image.NewColor(dielectricReflectance, dielectricReflectance, dielectricReflectance)
+
+	return dielectric.Multiply(1 - metallic).Add(baseColor.Multiply(metallic))
+}
+
+// returns the alpha of the GGX distribution for the roughness, which is squared so that
+// roughness looks even from 0 to 1.
+func roughnessAlpha(roughness float64) float64 {
+	r := max(roughness, minRoughness)
+
+	return r * r
+}
+
+// returns Schlick's approximation of the Fresnel reflectance for each color, where f0 is the
+// reflectance facing the surface, and cos is the cosine of the angle to the facet.
+func schlickColor(f0 *image.Color, cos float64) *image.Color {
+	return f0.Add(image.White.Subtract(f0).Multiply(math.Pow(1-cos, 5)))
+}
+
+// returns the density of facets facing the half vector (the GGX, or Trowbridge-Reitz, distribution),
+// for the cosine of its angle to the normal.
+func ggxDistribution(nDotH, alpha float64) float64 {
+	a2 := alpha * alpha
+	d := nDotH*nDotH*(a2-1) + 1
+
+	return a2 / (math.Pi * d * d)
+}
+
+// returns the fraction of facets that are visible from both the light and the eye (the Smith
+// masking-shadowing function for GGX).
+func smithMasking(nDotL, nDotV, alpha float64) float64 {
+	a2 := alpha * alpha
+	g1 := func(cos float64) float64 {
+		return 2 * cos / (cos + math.Sqrt(a2+(1-a2)*cos*cos))
+	}
+
+	return g1(nDotL) * g1(nDotV)
+}
+
+// returns the facet direction on the hemisphere around the normal for the u and v fractions
+// (from 0 to 1), where evenly spread u and v values give directions spread by the GGX distribution.
+func ggxHalfVector(normal *base.Tuple, alpha, u, v float64) *base.Tuple {
+	tangent, bitangent := tangents(normal)
+	cosTheta := math.Sqrt((1 - u) / (1 + (alpha*alpha-1)*u))
+	sinTheta := math.Sqrt(1 - cosTheta*cosTheta)
+	phi := 2 * math.Pi * v
+
+	return tangent.Multiply(sinTheta * math.Cos(phi)).
+		Add(bitangent.Multiply(sinTheta * math.Sin(phi))).
+		Add(normal.Multiply(cosTheta))
+}
+
+// returns the ray that a path continues along from a hit on a microfacet material, the fraction
+// of the light from that ray that is carried back along the path, and how the ray left the surface.
+// The ray passes through the material's transparent share, and is otherwise
+// either reflected off a facet picked from the GGX distribution, or scattered diffusely.
+// Returns a nil ray if the path ends at the hit.
+func scatterMicrofacet(hd *hitData, material *object.Material, rng *rand.Rand) (*ray.Ray, *image.Color, scatterKind) {
+	if rng.Float64() < material.Transparency {
+		direction := refractionDirection(hd)
+		if direction == nil {
+			// total internal reflection
+			return nil, nil, specularScatter
+		}
+		next := ray.NewRay(hd.underPoint, direction)
+		next.Time = hd.time
+
+		return next, image.White, specularScatter
+	}
+
+	baseColor := surfaceColor(hd.object, material, hd.point, hd.time)
+	f0 := facingReflectance(baseColor, material.Metallic)
+	alpha := roughnessAlpha(material.Roughness)
+	nDotV := hd.normalv.DotProduct(hd.eyev)
+
+	// metals only reflect, so facets are picked more often the more metallic the surface is
+	specularChance := 0.5 + 0.5*material.Metallic
+	if rng.Float64() < specularChance {
+		halfv := ggxHalfVector(hd.normalv, alpha, rng.Float64(), rng.Float64())
+		vDotH := hd.eyev.DotProduct(halfv)
+		direction := halfv.Multiply(2 * vDotH).Subtract(hd.eyev)
+		nDotL := hd.normalv.DotProduct(direction)
+		if nDotL <= 0 || vDotH <= 0 {
+			// the facet reflects the path into the surface
+			return nil, nil, glossyScatter
+		}
+		nDotH := hd.normalv.DotProduct(halfv)
+		// the reflectance divided by the chance of picking the direction
+		weight := schlickColor(f0, vDotH).Multiply(smithMasking(nDotL, nDotV, alpha) * vDotH / (nDotV * nDotH))
+		next := ray.NewRay(hd.overPoint, direction)
+		next.Time = hd.time
+
+		return next, weight.Multiply(1 / specularChance), glossyScatter
+	}
+
+	weight := image.White.Subtract(schlickColor(f0, nDotV)).MultiplyColor(baseColor).Multiply(1 - material.Metallic)
+	next := ray.NewRay(hd.overPoint, cosineDirection(hd.normalv, rng.Float64(), rng.Float64()))
+	next.Time = hd.time
+
+	return next, weight.Multiply(1 / (1 - specularChance)), diffuseScatter
+}
@@ -0,0 +1,227 @@
+package scene
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/object"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/ray"
+)
+
+// returns a microfacet material with the color, metallic, and roughness.
+func microfacetMaterial(color *image.Color, metallic, roughness float64) object.Material {
+	m := object.DefaultMaterial
+	m.Shader = MicrofacetShader
+	m.Color = color
+	m.Metallic = metallic
+	m.Roughness = roughness
+
+	return m
+}
+
+func TestMicrofacetLighting(t *testing.T) {
+	t.Parallel()
+
+	orange := image.NewColor(1, 0.5, 0)
+	tests := []struct {
+		name      string
+		material  object.Material
+		light     *PointLight
+		intensity *image.Color
+		expColor  *image.Color
+	}{
+		{
+			name:      "rough dielectric facing the light and eye",
+			material:  microfacetMaterial(image.White, 0, 1),
+			light:     NewPointLight(base.NewPoint(0, 0, -10), image.White),
+			intensity: image.White,
+			// 0.96 of the light is diffuse, and 0.04 is reflected by a quarter of the facets
+			expColor: image.NewColor(1.07, 1.07, 1.07),
+		},
+		{
+			name:      "rough metal facing the light and eye",
+			material:  microfacetMaterial(orange, 1, 1),
+			light:     NewPointLight(base.NewPoint(0, 0, -10), image.White),
+			intensity: image.White,
+			expColor:  image.NewColor(0.35, 0.175, 0),
+		},
+		{
+			name:      "in shadow",
+			material:  microfacetMaterial(orange, 1, 1),
+			light:     NewPointLight(base.NewPoint(0, 0, -10), image.White),
+			intensity: image.Black,
+			expColor:  image.NewColor(0.1, 0.05, 0),
+		},
+		{
+			name:      "light behind the surface",
+			material:  microfacetMaterial(image.White, 0, 0.5),
+			light:     NewPointLight(base.NewPoint(0, 0, 10), image.White),
+			intensity: image.White,
+			expColor:  image.NewColor(0.1, 0.1, 0.1),
+		},
+	}
+
+	eyev := base.NewVector(0, 0, -1)
+	normalv := base.NewVector(0, 0, -1)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			s := object.NewSphere()
+			result := microfacetLighting(
+				test.light, s, &test.material, base.Origin, eyev, normalv, 0, test.light.samplesFrom(base.Origin, testRand()), test.intensity)
+			g.Expect(result.Equals(test.expColor)).To(BeTrue())
+		})
+	}
+}
+
+func TestMicrofacetLighting_Roughness(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// the eye sees the mirror reflection of the light
+	light := NewPointLight(base.NewPoint(0, 10, -10), image.White)
+	eyev := base.NewVector(0, -math.Sqrt(2)/2, -math.Sqrt(2)/2)
+	normalv := base.NewVector(0, 0, -1)
+	offEyev := base.NewVector(0, 0, -1)
+	s := object.NewSphere()
+
+	highlight := func(m object.Material, eyev *base.Tuple) float64 {
+		return microfacetLighting(light, s, &m, base.Origin, eyev, normalv, 0, light.samplesFrom(base.Origin, testRand()), image.White).Luminance()
+	}
+	smooth := microfacetMaterial(image.White, 0, 0.1)
+	rough := microfacetMaterial(image.White, 0, 0.8)
+
+	// a smooth surface has a small, bright highlight, and a rough one spreads it out
+	g.Expect(highlight(smooth, eyev)).To(BeNumerically(">", 5*highlight(rough, eyev)))
+	g.Expect(highlight(smooth, offEyev)).To(BeNumerically("<", highlight(rough, offEyev)))
+	// the highlight of a metal is tinted by its color
+	red, green, _ := microfacetLighting(light, s, ptr(microfacetMaterial(image.NewColor(1, 0.5, 0), 1, 0.1)),
+		base.Origin, eyev, normalv, 0, light.samplesFrom(base.Origin, testRand()), image.White).RGB()
+	g.Expect(green / red).To(BeNumerically("~", 0.5, 0.01))
+	// a perfectly smooth surface is treated as slightly rough
+	g.Expect(highlight(microfacetMaterial(image.White, 0, 0), eyev)).To(
+		Equal(highlight(microfacetMaterial(image.White, 0, minRoughness), eyev)))
+}
+
+// returns a pointer to the material.
+func ptr(m object.Material) *object.Material {
+	return &m
+}
+
+func TestGGXHalfVector(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	normal := base.NewVector(0, 1, 1).Normalize()
+	for _, alpha := range []float64{0.01, 0.25, 1} {
+		for _, u := range []float64{0, 0.3, 0.9} {
+			for _, v := range []float64{0, 0.5} {
+				halfv := ggxHalfVector(normal, alpha, u, v)
+				g.Expect(halfv.Magnitude()).To(BeNumerically("~", 1, base.Epsilon))
+				g.Expect(halfv.DotProduct(normal)).To(BeNumerically(">=", 0))
+			}
+		}
+	}
+	// a rough distribution leans further from the normal
+	g.Expect(ggxHalfVector(normal, 0.01, 0.5, 0).DotProduct(normal)).To(
+		BeNumerically(">", ggxHalfVector(normal, 1, 0.5, 0).DotProduct(normal)))
+	g.Expect(ggxHalfVector(normal, 0.5, 0, 0).Equals(normal)).To(BeTrue())
+}
+
+func TestScatterMicrofacet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		material object.Material
+	}{
+		{
+			name:     "dielectric",
+			material: microfacetMaterial(image.NewColor(0.2, 0.8, 0.4), 0, 0.5),
+		},
+		{
+			name:     "smooth metal",
+			material: microfacetMaterial(image.NewColor(1, 0.8, 0.4), 1, 0.35),
+		},
+		{
+			name:     "rough metal",
+			material: microfacetMaterial(image.NewColor(1, 0.8, 0.4), 1, 0.7),
+		},
+	}
+
+	r := ray.NewRay(base.NewPoint(0, 1, -1), base.NewVector(0, -math.Sqrt(2)/2, math.Sqrt(2)/2))
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			floor := object.NewPlane()
+			floor.SetMaterial(&test.material)
+			ints := object.Intersections(object.NewIntersection(math.Sqrt(2), floor))
+			hd := prepareComputations(ints[0], r, ints)
+
+			// the light reflected from every direction, found from the reflectance, should match the
+			// average weight of the scattered paths
+			f0 := facingReflectance(test.material.Color, test.material.Metallic)
+			alpha := roughnessAlpha(test.material.Roughness)
+			rng := rand.New(rand.NewPCG(1, 2))
+			const n = 20000
+			expected, sampled := image.Black, image.Black
+			for range n {
+				// the chance of a cosine weighted direction cancels out the π and cosine in the reflectance
+				lightv := cosineDirection(hd.normalv, rng.Float64(), rng.Float64())
+				reflected := microfacetReflectance(
+					test.material.Color, f0, test.material.Metallic, alpha, hd.normalv, hd.eyev, lightv)
+				expected = expected.Add(reflected.Multiply(1 / lightv.DotProduct(hd.normalv)))
+
+				next, weight, kind := scatter(hd, rng)
+				if next == nil {
+					continue
+				}
+				g.Expect(next.Direction.DotProduct(hd.normalv)).To(BeNumerically(">", 0))
+				if test.material.Metallic == 1 {
+					g.Expect(kind).To(Equal(glossyScatter))
+				}
+				sampled = sampled.Add(weight)
+			}
+			expected = expected.Multiply(1.0 / n)
+			sampled = sampled.Multiply(1.0 / n)
+			expRed, expGreen, expBlue := expected.RGB()
+			red, green, blue := sampled.RGB()
+			g.Expect(red).To(BeNumerically("~", expRed, 0.03))
+			g.Expect(green).To(BeNumerically("~", expGreen, 0.03))
+			g.Expect(blue).To(BeNumerically("~", expBlue, 0.03))
+			// a surface can't reflect more light than it receives
+			g.Expect(max(red, green, blue)).To(BeNumerically("<=", 1))
+		})
+	}
+}
+
+func TestScatterMicrofacet_Transparent(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	m := microfacetMaterial(image.White, 0, 0.5)
+	m.Transparency = 1
+	floor := object.NewPlane()
+	floor.SetMaterial(&m)
+	r := ray.NewRay(base.NewPoint(0, 1, -1), base.NewVector(0, -math.Sqrt(2)/2, math.Sqrt(2)/2))
+	ints := object.Intersections(object.NewIntersection(math.Sqrt(2), floor))
+	hd := prepareComputations(ints[0], r, ints)
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 10 {
+		next, weight, kind := scatter(hd, rng)
+		g.Expect(next.Origin).To(Equal(hd.underPoint))
+		g.Expect(next.Direction.Equals(r.Direction)).To(BeTrue())
+		g.Expect(weight).To(Equal(image.White))
+		g.Expect(kind).To(Equal(specularScatter))
+	}
+}
@@ -37,6 +37,7 @@ func (cone *Cone) DeepCopy() Object {
 	newObj.SetMaterial(&newMaterial)
 	newTransform := cone.transform
 	newObj.SetTransform(&newTransform)
+	newObj.copyMotion(cone.object)
 
 	return newObj
 }
@@ -44,6 +44,7 @@ func (csg *Csg) DeepCopy() Object {
 	newObj.SetMaterial(&newMaterial)
 	newTransform := csg.transform
 	newObj.SetTransform(&newTransform)
+	newObj.copyMotion(csg.object)
 
 	if csg.bounds != nil {
 		newObj.bounds = csg.bounds.DeepCopy()
@@ -52,6 +53,11 @@ func (csg *Csg) DeepCopy() Object {
 	return newObj
 }
 
+// Children returns the csg's left and right objects.
+func (csg *Csg) Children() []Object {
+	return []Object{csg.left, csg.right}
+}
+
 // Bounds returns the bounding box for the csg of objects.
 func (csg *Csg) Bounds() *Bounds {
 	return csg.bounds
@@ -27,6 +27,7 @@ func (c *Cube) DeepCopy() Object {
 	newObj.SetMaterial(&newMaterial)
 	newTransform := c.transform
 	newObj.SetTransform(&newTransform)
+	newObj.copyMotion(c.object)
 
 	return newObj
 }
@@ -36,6 +36,7 @@ func (cyl *Cylinder) DeepCopy() Object {
 	newObj.SetMaterial(&newMaterial)
 	newTransform := cyl.transform
 	newObj.SetTransform(&newTransform)
+	newObj.copyMotion(cyl.object)
 
 	return newObj
 }
@@ -0,0 +1,342 @@
+package object
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+)
+
+// Displacement moves the vertices of a mesh along their normals, based on the brightness of
+// a pattern (or image) at each vertex.
+type Displacement struct {
+	Pattern image.Pattern
+	// distance a vertex moves at full brightness (relative to the midlevel)
+	Scale float64
+	// brightness (from 0 to 1) that leaves a vertex in place
+	Midlevel float64
+	// number of times each triangle is split into 4 before displacing
+	Subdivisions int
+}
+
+// Tessellate returns a group of triangles in the shape of a sphere or cube, so that it can be
+// displaced. Higher levels use more, smaller triangles. The group has the object's material
+// and transforms.
+func Tessellate(o Object, level int) (*Group, error) {
+	var triangles []Object
+	switch o.(type) {
+	case *Sphere:
+		triangles = tessellateSphere(level)
+	case *Cube:
+		triangles = tessellateCube(level)
+	default:
+		return nil, fmt.Errorf("cannot tessellate object of type %T", o)
+	}
+
+	g := NewGroup()
+	g.Add(triangles...)
+	g.SetMaterial(o.GetMaterial())
+	g.SetTransform(o.GetTransform())
+	g.copyMotion(o.baseObject())
+
+	return g, nil
+}
+
+// returns the smooth triangles of an icosahedron, with each triangle split into 4 for each level
+// and the new vertices pushed out onto the unit sphere.
+func tessellateSphere(level int) []Object {
+	// vertices of an icosahedron are the corners of 3 perpendicular golden rectangles
+	phi := (1 + math.Sqrt(5)) / 2
+	m := &mesh{}
+	for _, v := range [][3]float64{
+		{-1, phi, 0}, {1, phi, 0}, {-1, -phi, 0}, {1, -phi, 0},
+		{0, -1, phi}, {0, 1, phi}, {0, -1, -phi}, {0, 1, -phi},
+		{phi, 0, -1}, {phi, 0, 1}, {-phi, 0, -1}, {-phi, 0, 1},
+	} {
+		m.addVertex(base.NewVector(v[0], v[1], v[2]).Normalize())
+	}
+	for _, f := range [][3]int{
+		{0, 11, 5}, {0, 5, 1}, {0, 1, 7}, {0, 7, 10}, {0, 10, 11},
+		{1, 5, 9}, {5, 11, 4}, {11, 10, 2}, {10, 7, 6}, {7, 1, 8},
+		{3, 9, 4}, {3, 4, 2}, {3, 2, 6}, {3, 6, 8}, {3, 8, 9},
+		{4, 9, 5}, {2, 4, 11}, {6, 2, 10}, {8, 6, 7}, {9, 8, 1},
+	} {
+		m.addOutwardFace(f, nil)
+	}
+	for range level {
+		m.subdivide()
+		for i, v := range m.vertices {
+			m.vertices[i] = v.Normalize()
+		}
+	}
+
+	// the normal of a point on a unit sphere is the point itself
+	triangles := make([]Object, 0, len(m.faces))
+	for _, f := range m.faces {
+		p1, p2, p3 := m.point(f.v[0]), m.point(f.v[1]), m.point(f.v[2])
+		triangles = append(triangles, NewSmoothTriangle(p1, p2, p3,
+			m.vertices[f.v[0]], m.vertices[f.v[1]], m.vertices[f.v[2]]))
+	}
+
+	return triangles
+}
+
+// returns the triangles of a cube, with each face divided into a grid of 2^level by 2^level squares.
+func tessellateCube(level int) []Object {
+	cells := 1 << level
+	m := &mesh{}
+	// each face is its normal axis, and the two axes that span it
+	for _, axes := range [][3]int{{0, 1, 2}, {1, 2, 0}, {2, 0, 1}} {
+		for _, side := range []float64{-1, 1} {
+			index := make([][]int, cells+1)
+			for i := range index {
+				index[i] = make([]int, cells+1)
+				for j := range index[i] {
+					var coords [3]float64
+					coords[axes[0]] = side
+					coords[axes[1]] = -1 + 2*float64(i)/float64(cells)
+					coords[axes[2]] = -1 + 2*float64(j)/float64(cells)
+					index[i][j] = m.addVertex(base.NewVector(coords[0], coords[1], coords[2]))
+				}
+			}
+			for i := range cells {
+				for j := range cells {
+					m.addOutwardFace([3]int{index[i][j], index[i+1][j], index[i+1][j+1]}, nil)
+					m.addOutwardFace([3]int{index[i][j], index[i+1][j+1], index[i][j+1]}, nil)
+				}
+			}
+		}
+	}
+
+	triangles := make([]Object, 0, len(m.faces))
+	for _, f := range m.faces {
+		triangles = append(triangles, NewTriangle(m.point(f.v[0]), m.point(f.v[1]), m.point(f.v[2])))
+	}
+
+	return triangles
+}
+
+// Displace subdivides and displaces every triangle in the group (and its subgroups). Triangles
+// that share a vertex position are moved together, so a closed mesh stays closed. Each triangle
+// is replaced by smooth triangles with recalculated normals, and the bounds are recalculated.
+// The triangles are assumed to share a coordinate space, which the pattern is evaluated in.
+func (g *Group) Displace(d Displacement) {
+	m := &mesh{positions: map[vertexKey]int{}}
+	collectTriangles(g, m)
+	if len(m.faces) == 0 {
+		return
+	}
+
+	for range d.Subdivisions {
+		m.subdivide()
+	}
+	normals := m.vertexNormals()
+
+	// move each vertex along its normal (the direction of the original surface)
+	patternInverse := d.Pattern.GetTransform().Inverse()
+	for i, v := range m.vertices {
+		brightness := d.Pattern.PatternAt(patternInverse.MultiplyTuple(m.point(i))).Luminance()
+		m.vertices[i] = v.Add(normals[i].Multiply((brightness - d.Midlevel) * d.Scale))
+	}
+	// the original normals no longer match the surface
+	for i := range m.faces {
+		m.faces[i].normals = nil
+	}
+	normals = m.vertexNormals()
+
+	// replace each original triangle with its displaced pieces, in the same group
+	replacements := map[Object][]Object{}
+	for _, f := range m.faces {
+		n1, n2, n3 := normals[f.v[0]], normals[f.v[1]], normals[f.v[2]]
+		t := NewSmoothTriangle(m.point(f.v[0]), m.point(f.v[1]), m.point(f.v[2]), n1, n2, n3)
+		t.SetMaterial(f.source.GetMaterial())
+		t.SetTransform(f.source.GetTransform())
+		t.copyMotion(f.source.baseObject())
+		replacements[f.source] = append(replacements[f.source], t)
+	}
+	replaceTriangles(g, replacements)
+	if grp, ok := g.parent.(*Group); ok {
+		grp.bounds = calculateBounds(grp.Objects)
+	}
+}
+
+// adds the triangles of a group (and its subgroups) to the mesh.
+func collectTriangles(g *Group, m *mesh) {
+	for _, o := range g.Objects {
+		var t *Triangle
+		var normals []*base.Tuple
+		switch obj := o.(type) {
+		case *Group:
+			collectTriangles(obj, m)
+
+			continue
+		case *SmoothTriangle:
+			t = obj.Triangle
+			normals = []*base.Tuple{obj.N1, obj.N2, obj.N3}
+		case *Triangle:
+			t = obj
+		default:
+			continue
+		}
+
+		var f [3]int
+		for i, p := range []*base.Tuple{t.P1, t.P2, t.P3} {
+			f[i] = m.findOrAddVertex(p)
+		}
+		m.addFace(f, normals, o)
+	}
+}
+
+// replaces the triangles in a group (and its subgroups), and recalculates the bounds.
+func replaceTriangles(g *Group, replacements map[Object][]Object) {
+	objects := make([]Object, 0, len(g.Objects))
+	for _, o := range g.Objects {
+		if grp, ok := o.(*Group); ok {
+			replaceTriangles(grp, replacements)
+		}
+		if pieces, ok := replacements[o]; ok {
+			for _, p := range pieces {
+				p.SetParent(g)
+			}
+			objects = append(objects, pieces...)
+		} else {
+			objects = append(objects, o)
+		}
+	}
+	g.Objects = objects
+	g.bounds = calculateBounds(g.Objects)
+}
+
+// vertexKey identifies a vertex position, rounded so that nearly equal positions are the same.
+type vertexKey [3]int64
+
+func newVertexKey(p *base.Tuple) vertexKey {
+	const precision = 1e4
+
+	return vertexKey{
+		int64(math.Round(p.GetX() * precision)),
+		int64(math.Round(p.GetY() * precision)),
+		int64(math.Round(p.GetZ() * precision)),
+	}
+}
+
+// face is a triangle in a mesh, with the object it came from.
+type face struct {
+	v       [3]int
+	normals []*base.Tuple // smooth normals at each vertex, or nil if the face is flat
+	source  Object
+}
+
+// mesh is a list of triangles that share vertices. Vertices are stored as vectors, so that
+// they can be added and normalized.
+type mesh struct {
+	vertices  []*base.Tuple
+	positions map[vertexKey]int
+	faces     []face
+}
+
+func (m *mesh) addVertex(v *base.Tuple) int {
+	m.vertices = append(m.vertices, v)
+
+	return len(m.vertices) - 1
+}
+
+// returns the index of the vertex at the point, adding it if it doesn't exist.
+func (m *mesh) findOrAddVertex(p *base.Tuple) int {
+	key := newVertexKey(p)
+	if i, ok := m.positions[key]; ok {
+		return i
+	}
+	i := m.addVertex(base.NewVector(p.GetX(), p.GetY(), p.GetZ()))
+	m.positions[key] = i
+
+	return i
+}
+
+// returns the vertex as a point.
+func (m *mesh) point(i int) *base.Tuple {
+	v := m.vertices[i]
+
+	return base.NewPoint(v.GetX(), v.GetY(), v.GetZ())
+}
+
+func (m *mesh) addFace(v [3]int, normals []*base.Tuple, source Object) {
+	m.faces = append(m.faces, face{v: v, normals: normals, source: source})
+}
+
+// adds a face of a shape centered at the origin, with the vertices ordered so the normal
+// of the triangle points away from the origin.
+func (m *mesh) addOutwardFace(v [3]int, source Object) {
+	if m.faceNormal(v).DotProduct(m.vertices[v[0]]) < 0 {
+		v[1], v[2] = v[2], v[1]
+	}
+	m.addFace(v, nil, source)
+}
+
+// returns the normal of a face (in the same direction as a triangle's normal), with a
+// magnitude of twice the face's area.
+func (m *mesh) faceNormal(v [3]int) *base.Tuple {
+	e1 := m.vertices[v[1]].Subtract(m.vertices[v[0]])
+	e2 := m.vertices[v[2]].Subtract(m.vertices[v[0]])
+
+	return e2.CrossProduct(e1)
+}
+
+// splits each face into 4, using a shared vertex at the middle of each edge.
+func (m *mesh) subdivide() {
+	midpoints := map[[2]int]int{}
+	midpoint := func(a, b int) int {
+		edge := [2]int{min(a, b), max(a, b)}
+		if i, ok := midpoints[edge]; ok {
+			return i
+		}
+		i := m.addVertex(m.vertices[a].Add(m.vertices[b]).Multiply(0.5))
+		midpoints[edge] = i
+
+		return i
+	}
+
+	faces := make([]face, 0, 4*len(m.faces))
+	for _, f := range m.faces {
+		a, b, c := f.v[0], f.v[1], f.v[2]
+		ab, bc, ca := midpoint(a, b), midpoint(b, c), midpoint(c, a)
+
+		var nab, nbc, nca []*base.Tuple
+		if f.normals != nil {
+			na, nb, nc := f.normals[0], f.normals[1], f.normals[2]
+			mab, mbc, mca := na.Add(nb).Normalize(), nb.Add(nc).Normalize(), nc.Add(na).Normalize()
+			nab, nbc, nca = []*base.Tuple{na, mab, mca}, []*base.Tuple{mab, nb, mbc}, []*base.Tuple{mca, mbc, nc}
+			f.normals = []*base.Tuple{mab, mbc, mca}
+		}
+		faces = append(faces,
+			face{v: [3]int{a, ab, ca}, normals: nab, source: f.source},
+			face{v: [3]int{ab, b, bc}, normals: nbc, source: f.source},
+			face{v: [3]int{ca, bc, c}, normals: nca, source: f.source},
+			face{v: [3]int{ab, bc, ca}, normals: f.normals, source: f.source},
+		)
+	}
+	m.faces = faces
+}
+
+// returns the normal at each vertex. Smooth normals are averaged, and flat faces are weighted by area.
+func (m *mesh) vertexNormals() []*base.Tuple {
+	sums := make([]*base.Tuple, len(m.vertices))
+	for i := range sums {
+		sums[i] = base.NewVector(0, 0, 0)
+	}
+	for _, f := range m.faces {
+		for i, v := range f.v {
+			if f.normals != nil {
+				sums[v] = sums[v].Add(f.normals[i])
+			} else {
+				sums[v] = sums[v].Add(m.faceNormal(f.v))
+			}
+		}
+	}
+	for i, n := range sums {
+		sums[i] = n.Normalize()
+	}
+
+	return sums
+}
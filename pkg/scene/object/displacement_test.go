@@ -0,0 +1,206 @@
+package object
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/ray"
+)
+
+// returns all the triangles in a group and its subgroups.
+func allTriangles(grp *Group) []*Triangle {
+	var triangles []*Triangle
+	for _, o := range grp.Objects {
+		switch obj := o.(type) {
+		case *Group:
+			triangles = append(triangles, allTriangles(obj)...)
+		case *SmoothTriangle:
+			triangles = append(triangles, obj.Triangle)
+		case *Triangle:
+			triangles = append(triangles, obj)
+		}
+	}
+
+	return triangles
+}
+
+// returns the distance of a point from the origin.
+func distance(p *base.Tuple) float64 {
+	return p.Subtract(base.NewPoint(0, 0, 0)).Magnitude()
+}
+
+func TestTessellate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		obj       Object
+		level     int
+		triangles int
+		smooth    bool
+		radius    func(*base.Tuple) float64
+	}{
+		{
+			name:      "icosahedron",
+			obj:       NewSphere(),
+			level:     0,
+			triangles: 20,
+			smooth:    true,
+			radius:    distance,
+		},
+		{
+			name:      "sphere",
+			obj:       NewSphere(),
+			level:     2,
+			triangles: 320,
+			smooth:    true,
+			radius:    distance,
+		},
+		{
+			name:      "cube",
+			obj:       NewCube(),
+			level:     0,
+			triangles: 12,
+			radius:    maxComponent,
+		},
+		{
+			name:      "subdivided cube",
+			obj:       NewCube(),
+			level:     2,
+			triangles: 192,
+			radius:    maxComponent,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			test.obj.SetTransform(base.Translate(1, 2, 3))
+			test.obj.SetMaterial(glassMaterial())
+			grp, err := Tessellate(test.obj, test.level)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(grp.GetTransform()).To(Equal(test.obj.GetTransform()))
+			g.Expect(grp.Material).To(Equal(*glassMaterial()))
+
+			triangles := allTriangles(grp)
+			g.Expect(triangles).To(HaveLen(test.triangles))
+			for _, tri := range triangles {
+				g.Expect(tri.Material).To(Equal(*glassMaterial()))
+				// every point is on the surface, and every triangle faces outwards
+				for _, p := range []*base.Tuple{tri.P1, tri.P2, tri.P3} {
+					g.Expect(test.radius(p)).To(BeNumerically("~", 1, base.Epsilon))
+				}
+				g.Expect(tri.normal.DotProduct(tri.P1.Subtract(base.NewPoint(0, 0, 0)))).To(BeNumerically(">", 0))
+			}
+			_, smooth := grp.Objects[0].(*SmoothTriangle)
+			g.Expect(smooth).To(Equal(test.smooth))
+		})
+	}
+
+	g := NewWithT(t)
+	_, err := Tessellate(NewPlane(), 1)
+	g.Expect(err).To(HaveOccurred())
+}
+
+// returns the largest absolute component of a point, which is 1 on the surface of a cube.
+func maxComponent(p *base.Tuple) float64 {
+	return max(abs(p.GetX()), abs(p.GetY()), abs(p.GetZ()))
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
+
+func TestDisplace(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// a solid white pattern moves every vertex out by the scale, beyond the midlevel
+	white := image.NewStripePattern(image.White, image.White)
+	grp, err := Tessellate(NewSphere(), 2)
+	g.Expect(err).ToNot(HaveOccurred())
+	parent := NewGroup()
+	parent.Add(grp)
+	grp.Displace(Displacement{Pattern: white, Scale: 0.5, Midlevel: 0.5})
+
+	triangles := allTriangles(grp)
+	g.Expect(triangles).To(HaveLen(320))
+	for _, tri := range triangles {
+		g.Expect(distance(tri.P1)).To(BeNumerically("~", 1.25, base.Epsilon))
+	}
+	g.Expect(grp.Bounds().Maximum.GetY()).To(BeNumerically("~", 1.25, 0.01))
+	g.Expect(parent.Bounds().Maximum.GetY()).To(BeNumerically("~", 1.25, 0.01))
+
+	// a ray hits the displaced surface, with a normal facing outwards
+	r := ray.NewRay(base.NewPoint(0.1, 0.2, -5), base.NewVector(0, 0, 1))
+	ints := parent.Intersect(r)
+	g.Expect(ints).To(HaveLen(2))
+	point := r.Position(ints[0].Value)
+	g.Expect(distance(point)).To(BeNumerically("~", 1.25, 0.02))
+	normal := ints[0].Object.NormalAt(point, ints[0])
+	g.Expect(normal.GetZ()).To(BeNumerically("<", -0.95))
+}
+
+func TestDisplace_Subdivisions(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// two triangles sharing an edge, in different subgroups, displaced by a gradient along x
+	t1 := NewTriangle(base.NewPoint(0, 0, 0), base.NewPoint(1, 0, 0), base.NewPoint(0, 0, 1))
+	t2 := NewTriangle(base.NewPoint(1, 0, 0), base.NewPoint(1, 0, 1), base.NewPoint(0, 0, 1))
+	t2.SetMaterial(glassMaterial())
+	sub1, sub2 := NewGroup(), NewGroup()
+	sub1.Add(t1)
+	sub2.Add(t2)
+	grp := NewGroup()
+	grp.Add(sub1, sub2)
+	g.Expect(t1.normal).To(Equal(base.NewVector(0, 1, 0)))
+
+	gradient := image.NewGradientPattern(image.Black, image.White)
+	gradient.SetTransform(base.Scale(2, 1, 1))
+	grp.Displace(Displacement{Pattern: gradient, Scale: 1, Subdivisions: 1})
+
+	g.Expect(sub1.Objects).To(HaveLen(4))
+	g.Expect(sub2.Objects).To(HaveLen(4))
+	for _, o := range sub2.Objects {
+		g.Expect(o.GetParent()).To(Equal(sub2))
+		g.Expect(*o.GetMaterial()).To(Equal(*glassMaterial()))
+		st, ok := o.(*SmoothTriangle)
+		g.Expect(ok).To(BeTrue())
+		// each vertex is raised by half its x value, which is the gradient's brightness
+		for _, p := range []*base.Tuple{st.P1, st.P2, st.P3} {
+			g.Expect(p.GetY()).To(BeNumerically("~", p.GetX()/2, base.Epsilon))
+		}
+		g.Expect(st.N1.GetY()).To(BeNumerically(">", 0))
+	}
+	g.Expect(grp.Bounds().Maximum.GetY()).To(BeNumerically("~", 0.5, base.Epsilon))
+
+	// the shared edge is still shared
+	shared := 0
+	for _, a := range allTriangles(sub1) {
+		for _, b := range allTriangles(sub2) {
+			for _, p := range []*base.Tuple{a.P1, a.P2, a.P3} {
+				for _, q := range []*base.Tuple{b.P1, b.P2, b.P3} {
+					if p.Equals(q) {
+						shared++
+					}
+				}
+			}
+		}
+	}
+	g.Expect(shared).To(BeNumerically(">", 0))
+}
+
+// returns a material that differs from the default.
+func glassMaterial() *Material {
+	return GlassSphere().GetMaterial()
+}
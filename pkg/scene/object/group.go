@@ -33,6 +33,7 @@ func (g *Group) DeepCopy() Object {
 	newObj.SetMaterial(&newMaterial)
 	newTransform := g.transform
 	newObj.SetTransform(&newTransform)
+	newObj.copyMotion(g.object)
 
 	if g.bounds != nil {
 		newObj.bounds = g.bounds.DeepCopy()
@@ -54,7 +55,13 @@ func (g *Group) SetMaterial(material *Material) {
 	g.Material = *material
 }
 
-// calculates the bounds based on supplied objects (for group and csg).
+// The number of times during a moving object's motion that its bounds are found at.
+const motionBoundsSteps = 16
+
+// calculates the bounds based on supplied objects (for group and csg). The bounds of moving
+// objects cover their whole motion, from the positions of each corner at steps through the motion.
+// A corner can curve away from the line between two steps (such as when the object turns), so the
+// positions are padded by twice how far the corner is from the line halfway between the steps.
 func calculateBounds(objects []Object) *Bounds {
 	minX, minY, minZ := math.Inf(1), math.Inf(1), math.Inf(1)
 	maxX, maxY, maxZ := math.Inf(-1), math.Inf(-1), math.Inf(-1)
@@ -64,31 +71,49 @@ func calculateBounds(objects []Object) *Bounds {
 		if objBounds == nil {
 			continue
 		}
-		// bounding box points for the object (in parent space)
-		points := []*base.Tuple{
-			o.GetTransform().MultiplyTuple(objBounds.Minimum),
-			o.GetTransform().MultiplyTuple(
-				base.NewPoint(objBounds.Minimum.GetX(), objBounds.Minimum.GetY(), objBounds.Maximum.GetZ())),
-			o.GetTransform().MultiplyTuple(
-				base.NewPoint(objBounds.Minimum.GetX(), objBounds.Maximum.GetY(), objBounds.Minimum.GetZ())),
-			o.GetTransform().MultiplyTuple(
-				base.NewPoint(objBounds.Minimum.GetX(), objBounds.Maximum.GetY(), objBounds.Maximum.GetZ())),
-			o.GetTransform().MultiplyTuple(
-				base.NewPoint(objBounds.Maximum.GetX(), objBounds.Minimum.GetY(), objBounds.Minimum.GetZ())),
-			o.GetTransform().MultiplyTuple(
-				base.NewPoint(objBounds.Maximum.GetX(), objBounds.Minimum.GetY(), objBounds.Maximum.GetZ())),
-			o.GetTransform().MultiplyTuple(
-				base.NewPoint(objBounds.Maximum.GetX(), objBounds.Maximum.GetY(), objBounds.Minimum.GetZ())),
-			o.GetTransform().MultiplyTuple(objBounds.Maximum),
+		transforms := []*base.Matrix{o.GetTransform()}
+		if o.GetEndTransform() != nil {
+			// the steps, with the transforms halfway between them
+			for i := 1; i <= 2*motionBoundsSteps; i++ {
+				transforms = append(transforms, o.transformAt(float64(i)/(2*motionBoundsSteps)))
+			}
+		}
+		// bounding box corners for the object
+		corners := []*base.Tuple{
+			objBounds.Minimum,
+			base.NewPoint(objBounds.Minimum.GetX(), objBounds.Minimum.GetY(), objBounds.Maximum.GetZ()),
+			base.NewPoint(objBounds.Minimum.GetX(), objBounds.Maximum.GetY(), objBounds.Minimum.GetZ()),
+			base.NewPoint(objBounds.Minimum.GetX(), objBounds.Maximum.GetY(), objBounds.Maximum.GetZ()),
+			base.NewPoint(objBounds.Maximum.GetX(), objBounds.Minimum.GetY(), objBounds.Minimum.GetZ()),
+			base.NewPoint(objBounds.Maximum.GetX(), objBounds.Minimum.GetY(), objBounds.Maximum.GetZ()),
+			base.NewPoint(objBounds.Maximum.GetX(), objBounds.Maximum.GetY(), objBounds.Minimum.GetZ()),
+			objBounds.Maximum,
 		}
-		for _, p := range points {
-			minX = math.Min(minX, p.GetX())
-			minY = math.Min(minY, p.GetY())
-			minZ = math.Min(minZ, p.GetZ())
-
-			maxX = math.Max(maxX, p.GetX())
-			maxY = math.Max(maxY, p.GetY())
-			maxZ = math.Max(maxZ, p.GetZ())
+		for _, corner := range corners {
+			// corner of the bounding box in parent space
+			p := transforms[0].MultiplyTuple(corner)
+			points, pads := []*base.Tuple{p}, []float64{0}
+			for i := 2; i < len(transforms); i += 2 {
+				halfway := transforms[i-1].MultiplyTuple(corner)
+				next := transforms[i].MultiplyTuple(corner)
+				pad := 2 * halfway.Subtract(p.Add(next.Subtract(p).Multiply(0.5))).Magnitude()
+				if math.IsNaN(pad) {
+					// the corner is infinitely far away
+					pad = 0
+				}
+				points, pads = append(points, p, next), append(pads, pad, pad)
+				p = next
+			}
+
+			for j, q := range points {
+				minX = math.Min(minX, q.GetX()-pads[j])
+				minY = math.Min(minY, q.GetY()-pads[j])
+				minZ = math.Min(minZ, q.GetZ()-pads[j])
+
+				maxX = math.Max(maxX, q.GetX()+pads[j])
+				maxY = math.Max(maxY, q.GetY()+pads[j])
+				maxZ = math.Max(maxZ, q.GetZ()+pads[j])
+			}
 		}
 	}
 
@@ -141,6 +166,11 @@ func (g *Group) partitionChildren() ([]Object, []Object) {
 		if bMin.GreaterThan(bMax) {
 			bMin, bMax = bMax, bMin
 		}
+		if o.GetEndTransform() != nil {
+			// a moving object needs to fit for its whole motion
+			motionBounds := calculateBounds([]Object{o})
+			bMin, bMax = motionBounds.Minimum, motionBounds.Maximum
+		}
 		// check which box the object fits in, otherwise don't put it in one
 		if (bMin.GreaterThan(leftBox.Minimum) || bMin.Equals(leftBox.Minimum)) &&
 			(bMax.LessThan(leftBox.Maximum) || bMax.Equals(leftBox.Maximum)) {
@@ -1,6 +1,7 @@
 package object
 
 import (
+	"math"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -83,6 +84,40 @@ func TestBounds(t *testing.T) {
 	g.Expect(box.Maximum).To(Equal(base.NewPoint(4, 7, 4.5)))
 }
 
+func TestBounds_Motion(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	group := NewGroup()
+	s := NewSphere()
+	s.SetTransform(base.Translate(2, 0, 0))
+	s.SetEndTransform(base.Translate(6, 1, 0))
+	group.Add(s)
+
+	// bounds cover the start and end positions of the sphere
+	box := group.Bounds()
+	g.Expect(box.Minimum).To(Equal(base.NewPoint(1, -1, -1)))
+	g.Expect(box.Maximum).To(Equal(base.NewPoint(7, 2, 1)))
+
+	// a ray at the end of the motion hits the moved sphere through the group
+	r := ray.NewRay(base.NewPoint(6, 1, -5), base.NewVector(0, 0, 1))
+	g.Expect(group.Intersect(r)).To(BeEmpty())
+	r.Time = 1
+	g.Expect(group.Intersect(r)).To(HaveLen(2))
+
+	// bounds cover the arc of an orbiting sphere, past its start and end positions
+	group = NewGroup()
+	s = NewSphere()
+	s.SetTransform(base.RotateY(0), base.Translate(2, 0, 0))
+	s.SetEndTransform(base.RotateY(2*math.Pi/3), base.Translate(2, 0, 0))
+	group.Add(s)
+	box = group.Bounds()
+	g.Expect(box.Minimum.GetZ()).To(BeNumerically("<=", -3))
+	r = ray.NewRay(base.NewPoint(0, 5, -2.9), base.NewVector(0, -1, 0))
+	r.Time = 0.75
+	g.Expect(group.Intersect(r)).To(HaveLen(2))
+}
+
 func TestDivide(t *testing.T) {
 	t.Parallel()
 	g := NewWithT(t)
@@ -6,6 +6,7 @@ import "sort"
 type Intersection struct {
 	Value  float64
 	Object Object
+	Time   float64 // time of the ray that made the intersection
 	u, v   float64 // only used for triangles
 }
 
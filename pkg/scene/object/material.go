@@ -6,14 +6,34 @@ import "github.com/sjberman/golang-ray-tracer/pkg/image"
 type Material struct {
 	Color           *image.Color
 	Pattern         image.Pattern
+	Shader          string // name of the registered shader that lights the surface, or "" for Phong
 	Ambient         float64
 	Diffuse         float64
 	Specular        float64
 	Shininess       float64
+	Metallic        float64 // microfacet shader: 0 for a dielectric (like plastic), to 1 for a bare metal
+	Roughness       float64 // microfacet shader: 0 for a smooth surface, to 1 for a rough one
 	Reflective      float64
 	Transparency    float64
 	RefractiveIndex float64
 	Shadow          bool
+	// the medium that fills the inside of the object (nil for none), which is seen through
+	// its transparent surface
+	Volume *Volume
+	// the light that the surface gives off (nil for none), which is added to its color however it's lit
+	Emission *image.Color
+	// the number of points on the surface that the emission lights other objects from, or 0 if the
+	// surface only glows
+	EmissionSamples int
+}
+
+// Volume is a uniform medium, like smoke or colored liquid, which fades the light passing through it
+// to the medium's color.
+type Volume struct {
+	// how quickly the medium hides what's behind it, where e^(-Density * distance) of the light
+	// passes through
+	Density float64
+	Color   *image.Color
 }
 
 var DefaultMaterial = Material{
@@ -22,6 +42,8 @@ var DefaultMaterial = Material{
 	Diffuse:         0.9,
 	Specular:        0.9,
 	Shininess:       200,
+	Metallic:        0,
+	Roughness:       0.5,
 	Reflective:      0,
 	Transparency:    0,
 	RefractiveIndex: 1.0,
@@ -11,17 +11,21 @@ import (
 type Object interface {
 	GetMaterial() *Material
 	GetTransform() *base.Matrix
+	GetEndTransform() *base.Matrix
 	GetParent() Object
 	Bounds() *Bounds
 	SetTransform(...*base.Matrix)
+	SetEndTransform(...*base.Matrix)
 	SetMaterial(*Material)
 	SetParent(Object)
-	PatternAt(*base.Tuple, image.Pattern) *image.Color
+	PatternAt(*base.Tuple, float64, image.Pattern) *image.Color
 	Intersect(*ray.Ray) []*Intersection
 	NormalAt(*base.Tuple, *Intersection) *base.Tuple
 	Divide(int)
-	worldToObject(*base.Tuple) *base.Tuple
-	normalToWorld(*base.Tuple) *base.Tuple
+	worldToObject(*base.Tuple, float64) *base.Tuple
+	normalToWorld(*base.Tuple, float64) *base.Tuple
+	transformAt(float64) *base.Matrix
+	baseObject() *object
 	DeepCopy() Object
 }
 
@@ -29,7 +33,11 @@ type Object interface {
 type object struct {
 	Material
 	transform base.Matrix
-	parent    Object
+	// the transforms that make up the transform, which each move to one of the end transforms
+	transforms    []*base.Matrix
+	endTransforms []*base.Matrix // nil if the object doesn't move
+	motion        *base.Motion   // nil if the object doesn't move
+	parent        Object
 }
 
 // newObject returns a new object.
@@ -45,6 +53,30 @@ func (o *object) GetTransform() *base.Matrix {
 	return &o.transform
 }
 
+// GetEndTransform gets the Object's transform matrix at the end of its motion,
+// or nil if the object doesn't move.
+func (o *object) GetEndTransform() *base.Matrix {
+	if o.motion == nil {
+		return nil
+	}
+
+	return o.motion.End()
+}
+
+// returns the Object's transform matrix at the time (from 0 to 1) during its motion.
+func (o *object) transformAt(time float64) *base.Matrix {
+	if o.motion == nil || time <= 0 {
+		return &o.transform
+	}
+
+	return o.motion.At(time)
+}
+
+// returns the object that the Object is built on.
+func (o *object) baseObject() *object {
+	return o
+}
+
 // GetMaterial gets the Object's material.
 func (o *object) GetMaterial() *Material {
 	return &o.Material
@@ -62,6 +94,31 @@ func (o *object) SetTransform(matrix ...*base.Matrix) {
 		t = *t.Multiply(m)
 	}
 	o.transform = t
+	o.transforms = append([]*base.Matrix{}, matrix...)
+	o.updateMotion()
+}
+
+// SetEndTransform sets the Object's transform at the end of its motion to the supplied matrix.
+// The object moves from its transform at time 0 to its end transform at time 1, which causes
+// motion blur for rays sent at different times. If the end transform is made up of as many
+// matrices as the transform, each matrix moves to its end matrix, as a base.Motion does.
+func (o *object) SetEndTransform(matrix ...*base.Matrix) {
+	o.endTransforms = append([]*base.Matrix{}, matrix...)
+	o.updateMotion()
+}
+
+// updates the Object's motion from its transforms.
+func (o *object) updateMotion() {
+	if o.endTransforms == nil {
+		o.motion = nil
+		return
+	}
+	o.motion = base.NewMotion(o.transforms, o.endTransforms)
+}
+
+// copies the motion of another object that has the same transform.
+func (o *object) copyMotion(from *object) {
+	o.transforms, o.endTransforms, o.motion = from.transforms, from.endTransforms, from.motion
 }
 
 // SetMaterial sets the Object's material.
@@ -74,10 +131,10 @@ func (o *object) SetParent(obj Object) {
 	o.parent = obj
 }
 
-// patternAt returns the pattern at a point on the object.
-func (o *object) PatternAt(worldPoint *base.Tuple, pattern image.Pattern) *image.Color {
+// patternAt returns the pattern at a point on the object at the time of the ray.
+func (o *object) PatternAt(worldPoint *base.Tuple, time float64, pattern image.Pattern) *image.Color {
 	// convert the point from world space to object space
-	objectPoint := o.worldToObject(worldPoint)
+	objectPoint := o.worldToObject(worldPoint, time)
 
 	// convert point to pattern space
 	patternInverse := pattern.GetTransform().Inverse()
@@ -89,7 +146,7 @@ func (o *object) PatternAt(worldPoint *base.Tuple, pattern image.Pattern) *image
 // transform the ray to the inverse of the object's transform;
 // this is the same as transforming the object.
 func (o *object) transformRay(r *ray.Ray) *ray.Ray {
-	objInverse := o.GetTransform().Inverse()
+	objInverse := o.transformAt(r.Time).Inverse()
 
 	return r.Transform(objInverse)
 }
@@ -101,31 +158,35 @@ func commonNormalAt(
 	hit *Intersection,
 	objectNormalFunc func(*base.Tuple, Object, *Intersection) *base.Tuple,
 ) *base.Tuple {
-	objectPoint := o.worldToObject(worldPoint)
+	var time float64
+	if hit != nil {
+		time = hit.Time
+	}
+	objectPoint := o.worldToObject(worldPoint, time)
 	objectNormal := objectNormalFunc(objectPoint, o, hit)
 
-	return o.normalToWorld(objectNormal)
+	return o.normalToWorld(objectNormal, time)
 }
 
-// converts a point in world space to object space.
-func (o *object) worldToObject(point *base.Tuple) *base.Tuple {
+// converts a point in world space to object space at the time.
+func (o *object) worldToObject(point *base.Tuple, time float64) *base.Tuple {
 	if o.parent != nil {
-		point = o.parent.worldToObject(point)
+		point = o.parent.worldToObject(point, time)
 	}
-	inverse := o.transform.Inverse()
+	inverse := o.transformAt(time).Inverse()
 
 	return inverse.MultiplyTuple(point)
 }
 
-// converts a normal in object space to world space.
-func (o *object) normalToWorld(normal *base.Tuple) *base.Tuple {
-	inverse := o.GetTransform().Inverse()
+// converts a normal in object space to world space at the time.
+func (o *object) normalToWorld(normal *base.Tuple, time float64) *base.Tuple {
+	inverse := o.transformAt(time).Inverse()
 	normal = inverse.Transpose().MultiplyTuple(normal)
 	normal.SetW(0)
 	normal = normal.Normalize()
 
 	if o.parent != nil {
-		normal = o.parent.normalToWorld(normal)
+		normal = o.parent.normalToWorld(normal, time)
 	}
 
 	return normal
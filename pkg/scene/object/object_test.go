@@ -8,6 +8,7 @@ import (
 
 	"github.com/sjberman/golang-ray-tracer/pkg/base"
 	"github.com/sjberman/golang-ray-tracer/pkg/image"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/ray"
 )
 
 func testNewObject(g *WithT, o Object) {
@@ -57,14 +58,14 @@ func TestPatternAt(t *testing.T) {
 	s := NewSphere()
 	s.SetTransform(base.Scale(2, 2, 2))
 	p := image.NewMockPattern()
-	c := s.PatternAt(base.NewPoint(2, 3, 4), p)
+	c := s.PatternAt(base.NewPoint(2, 3, 4), 0, p)
 	g.Expect(c).To(Equal(image.NewColor(1, 1.5, 2)))
 
 	// with pattern transformation
 	s = NewSphere()
 	p = image.NewMockPattern()
 	p.SetTransform(base.Scale(2, 2, 2))
-	c = s.PatternAt(base.NewPoint(2, 3, 4), p)
+	c = s.PatternAt(base.NewPoint(2, 3, 4), 0, p)
 	g.Expect(c).To(Equal(image.NewColor(1, 1.5, 2)))
 
 	// with both object and pattern transformation
@@ -72,10 +73,57 @@ func TestPatternAt(t *testing.T) {
 	s.SetTransform(base.Scale(2, 2, 2))
 	p = image.NewMockPattern()
 	p.SetTransform(base.Translate(0.5, 1, 1.5))
-	c = s.PatternAt(base.NewPoint(2.5, 3, 3.5), p)
+	c = s.PatternAt(base.NewPoint(2.5, 3, 3.5), 0, p)
 	g.Expect(c).To(Equal(image.NewColor(0.75, 0.5, 0.25)))
 }
 
+func TestEndTransform(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	s := NewSphere()
+	g.Expect(s.GetEndTransform()).To(BeNil())
+	s.SetTransform(base.Translate(0, 0, 0))
+	g.Expect(s.transformAt(0.5)).To(Equal(s.GetTransform()))
+
+	s.SetEndTransform(base.Translate(2, 0, 0), base.Scale(2, 2, 2))
+	g.Expect(s.GetEndTransform()).To(Equal(base.Translate(2, 0, 0).Multiply(base.Scale(2, 2, 2))))
+	g.Expect(s.transformAt(0)).To(Equal(s.GetTransform()))
+	g.Expect(s.transformAt(1)).To(Equal(s.GetEndTransform()))
+	g.Expect(s.transformAt(0.5)).To(Equal(base.Translate(1, 0, 0).Multiply(base.Scale(1.5, 1.5, 1.5))))
+	g.Expect(s.transformAt(2)).To(Equal(s.GetEndTransform()))
+
+	// ray time determines where the object is
+	r := ray.NewRay(base.NewPoint(2, 0, -5), base.NewVector(0, 0, 1))
+	g.Expect(s.Intersect(r)).To(BeEmpty())
+	r.Time = 1
+	ints := s.Intersect(r)
+	g.Expect(ints).To(HaveLen(2))
+	g.Expect(ints[0].Value).To(Equal(3.0))
+
+	hit := ints[0]
+	hit.Time = 1
+	g.Expect(s.NormalAt(r.Position(hit.Value), hit)).To(Equal(base.NewVector(0, 0, -1)))
+	g.Expect(s.worldToObject(base.NewPoint(2, 0, -2), 1)).To(Equal(base.NewPoint(0, 0, -1)))
+
+	// copies keep the motion
+	g.Expect(s.DeepCopy().GetEndTransform()).To(Equal(s.GetEndTransform()))
+
+	// a turning sphere keeps its size
+	s = NewSphere()
+	s.SetEndTransform(base.RotateY(math.Pi / 2))
+	r = ray.NewRay(base.NewPoint(0.9, 0, -5), base.NewVector(0, 0, 1))
+	r.Time = 0.5
+	g.Expect(s.Intersect(r)).To(HaveLen(2))
+
+	// an orbiting sphere follows an arc
+	s = NewSphere()
+	s.SetTransform(base.RotateY(0), base.Translate(2, 0, 0))
+	s.SetEndTransform(base.RotateY(math.Pi/2), base.Translate(2, 0, 0))
+	center := s.transformAt(0.5).MultiplyTuple(base.Origin)
+	g.Expect(center.Equals(base.NewPoint(math.Sqrt2, 0, -math.Sqrt2))).To(BeTrue())
+}
+
 func TestWorldToObject(t *testing.T) {
 	t.Parallel()
 	g := NewWithT(t)
@@ -90,7 +138,7 @@ func TestWorldToObject(t *testing.T) {
 	s.SetTransform(base.Translate(5, 0, 0))
 	g2.Add(s)
 
-	p := s.worldToObject(base.NewPoint(-2, 0, -10))
+	p := s.worldToObject(base.NewPoint(-2, 0, -10), 0)
 	g.Expect(p).To(Equal(base.NewPoint(0, 0, -1)))
 }
 
@@ -108,7 +156,7 @@ func TestNormalToWorld(t *testing.T) {
 	s.SetTransform(base.Translate(5, 0, 0))
 	g2.Add(s)
 
-	v := s.normalToWorld(base.NewVector(math.Sqrt(3)/3, math.Sqrt(3)/3, math.Sqrt(3)/3))
+	v := s.normalToWorld(base.NewVector(math.Sqrt(3)/3, math.Sqrt(3)/3, math.Sqrt(3)/3), 0)
 	g.Expect(v).To(Equal(base.NewVector(0.28571428571428575, 0.4285714285714286, -0.8571428571428572)))
 }
 
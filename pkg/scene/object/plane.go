@@ -26,6 +26,7 @@ func (p *Plane) DeepCopy() Object {
 	newObj.SetMaterial(&newMaterial)
 	newTransform := p.transform
 	newObj.SetTransform(&newTransform)
+	newObj.copyMotion(p.object)
 
 	return newObj
 }
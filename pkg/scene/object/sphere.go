@@ -26,6 +26,7 @@ func (s *Sphere) DeepCopy() Object {
 	newObj.SetMaterial(&newMaterial)
 	newTransform := s.transform
 	newObj.SetTransform(&newTransform)
+	newObj.copyMotion(s.object)
 
 	return newObj
 }
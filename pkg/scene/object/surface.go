@@ -0,0 +1,129 @@
+package object
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/ray"
+)
+
+// The most lines tried for each surface point, in case most of them miss a small or thin object.
+const maxLinesPerPoint = 50
+
+// SurfacePoint is a point on the outer surface of an object, with the surface's outward normal.
+// Both are in world space.
+type SurfacePoint struct {
+	Point  *base.Tuple
+	Normal *base.Tuple
+}
+
+// SurfacePoints returns up to count points on the outer surface of the object (at the start of its
+// motion), where random lines through the object's bounds enter and leave it. Lines spread evenly in
+// every direction cross a surface in proportion to its area, so the points are spread evenly over
+// the surface. For an object in a csg, only the points on the csg's surface are kept. Returns nil
+// for an object without finite bounds, like a plane.
+func SurfacePoints(o Object, count int, rng *rand.Rand) []SurfacePoint {
+	// bounds in the space of the object's parent, which is where its Intersect method works
+	bounds := calculateBounds([]Object{o})
+	diagonal := bounds.Maximum.Subtract(bounds.Minimum)
+	if math.IsInf(diagonal.Magnitude(), 0) || math.IsNaN(diagonal.Magnitude()) {
+		return nil
+	}
+	center := bounds.Minimum.Add(diagonal.Multiply(0.5))
+	radius := diagonal.Magnitude() / 2
+
+	points := make([]SurfacePoint, 0, count)
+	for range count * maxLinesPerPoint {
+		if len(points) >= count {
+			break
+		}
+		r := randomLine(center, radius, rng)
+		ints := Intersections(o.Intersect(r)...)
+		if len(ints) == 0 {
+			continue
+		}
+		// the line enters the object at its first intersection and leaves at its last one
+		if point, ok := surfacePoint(o, r, ints[0], -1); ok {
+			points = append(points, point)
+		}
+		if len(points) < count && len(ints) > 1 {
+			if point, ok := surfacePoint(o, r, ints[len(ints)-1], 1); ok {
+				points = append(points, point)
+			}
+		}
+	}
+
+	return points
+}
+
+// returns a ray along a random line through the sphere, starting outside of it. The direction is
+// evenly spread over every direction, and the line is evenly spread over the disk across the sphere
+// that is perpendicular to it.
+func randomLine(center *base.Tuple, radius float64, rng *rand.Rand) *ray.Ray {
+	z := 1 - 2*rng.Float64()
+	phi := 2 * math.Pi * rng.Float64()
+	sinTheta := math.Sqrt(1 - z*z)
+	direction := base.NewVector(sinTheta*math.Cos(phi), sinTheta*math.Sin(phi), z)
+
+	// two vectors across the disk
+	helper := base.NewVector(1, 0, 0)
+	if math.Abs(direction.GetX()) > 0.9 {
+		helper = base.NewVector(0, 1, 0)
+	}
+	uvec := direction.CrossProduct(helper).Normalize()
+	vvec := direction.CrossProduct(uvec)
+
+	distance := radius * math.Sqrt(rng.Float64())
+	theta := 2 * math.Pi * rng.Float64()
+	origin := center.Add(uvec.Multiply(distance * math.Cos(theta))).
+		Add(vvec.Multiply(distance * math.Sin(theta))).
+		Subtract(direction.Multiply(2 * radius))
+
+	return ray.NewRay(origin, direction)
+}
+
+// returns the point in world space where the ray (in the space of the object's parent) crosses the
+// surface, with the normal facing against the ray if side is -1, or along it if side is 1. Also
+// returns false if the point isn't on the surface of a csg that the object is part of, because the
+// csg cuts it away or hides it inside the csg's other object.
+func surfacePoint(o Object, r *ray.Ray, hit *Intersection, side float64) (SurfacePoint, bool) {
+	point := r.Position(hit.Value)
+	direction := r.Direction
+	child := o
+	for parent := o.GetParent(); parent != nil; child, parent = parent, parent.GetParent() {
+		point = parent.GetTransform().MultiplyTuple(point)
+		direction = parent.GetTransform().MultiplyTuple(direction)
+		// the ray in the space of the parent's parent, which is where the parent's Intersect method works
+		r = r.Transform(parent.GetTransform())
+		csg, ok := parent.(*Csg)
+		if !ok {
+			continue
+		}
+		if !hasHit(csg.Intersect(r), hit) {
+			return SurfacePoint{}, false
+		}
+		if csg.operation == difference && includes(csg.right, child) {
+			// the surface of the hole that a difference cuts out faces into the hole
+			side = -side
+		}
+	}
+	normal := hit.Object.NormalAt(point, hit)
+	if normal.DotProduct(direction)*side < 0 {
+		// the normals of triangles can face either way
+		normal = normal.Negate()
+	}
+
+	return SurfacePoint{Point: point, Normal: normal}, true
+}
+
+// returns whether the intersections include the hit.
+func hasHit(ints []*Intersection, hit *Intersection) bool {
+	for _, i := range ints {
+		if i.Object == hit.Object && base.EqualFloats(i.Value, hit.Value) {
+			return true
+		}
+	}
+
+	return false
+}
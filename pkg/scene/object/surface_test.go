@@ -0,0 +1,139 @@
+package object
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+)
+
+func TestSurfacePoints(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		object func() Object
+		// checks a point and its normal
+		check func(g *WithT, p SurfacePoint)
+	}{
+		{
+			name: "sphere",
+			object: func() Object {
+				s := NewSphere()
+				s.SetTransform(base.Translate(1, 2, 3), base.Scale(2, 2, 2))
+
+				return s
+			},
+			check: func(g *WithT, p SurfacePoint) {
+				fromCenter := p.Point.Subtract(base.NewPoint(1, 2, 3))
+				g.Expect(fromCenter.Magnitude()).To(BeNumerically("~", 2, base.Epsilon))
+				g.Expect(p.Normal.Equals(fromCenter.Normalize())).To(BeTrue())
+			},
+		},
+		{
+			name: "sphere in a group",
+			object: func() Object {
+				s := NewSphere()
+				s.SetTransform(base.Translate(1, 0, 0))
+				grp := NewGroup()
+				grp.SetTransform(base.Translate(0, 5, 0))
+				grp.Add(s)
+
+				return s
+			},
+			check: func(g *WithT, p SurfacePoint) {
+				fromCenter := p.Point.Subtract(base.NewPoint(1, 5, 0))
+				g.Expect(fromCenter.Magnitude()).To(BeNumerically("~", 1, base.Epsilon))
+				g.Expect(p.Normal.Equals(fromCenter.Normalize())).To(BeTrue())
+			},
+		},
+		{
+			name: "sphere in a union",
+			object: func() Object {
+				s := NewSphere()
+				other := NewSphere()
+				other.SetTransform(base.Translate(1, 0, 0))
+				NewCsg(union, s, other)
+
+				return s
+			},
+			check: func(g *WithT, p SurfacePoint) {
+				g.Expect(p.Point.Subtract(base.Origin).Magnitude()).To(BeNumerically("~", 1, base.Epsilon))
+				// the part inside the other sphere isn't on the csg's surface
+				g.Expect(p.Point.Subtract(base.NewPoint(1, 0, 0)).Magnitude()).To(BeNumerically(">=", 1-base.Epsilon))
+				g.Expect(p.Normal.Equals(p.Point.Subtract(base.Origin).Normalize())).To(BeTrue())
+			},
+		},
+		{
+			name: "sphere cut out by a difference",
+			object: func() Object {
+				s := NewSphere()
+				s.SetTransform(base.Translate(1, 0, 0))
+				NewCsg(difference, NewSphere(), s)
+
+				return s
+			},
+			check: func(g *WithT, p SurfacePoint) {
+				fromCenter := p.Point.Subtract(base.NewPoint(1, 0, 0))
+				g.Expect(fromCenter.Magnitude()).To(BeNumerically("~", 1, base.Epsilon))
+				// only the part inside the other sphere is on the csg's surface, facing into the hole
+				g.Expect(p.Point.Subtract(base.Origin).Magnitude()).To(BeNumerically("<=", 1+base.Epsilon))
+				g.Expect(p.Normal.Equals(fromCenter.Normalize().Negate())).To(BeTrue())
+			},
+		},
+		{
+			name: "triangle",
+			object: func() Object {
+				return NewTriangle(base.NewPoint(0, 0, 0), base.NewPoint(1, 0, 0), base.NewPoint(0, 0, 1))
+			},
+			check: func(g *WithT, p SurfacePoint) {
+				g.Expect(p.Point.GetY()).To(BeNumerically("~", 0, base.Epsilon))
+				g.Expect(p.Point.GetX() + p.Point.GetZ()).To(BeNumerically("<=", 1+base.Epsilon))
+				// a flat surface has a point on each side, facing away from it
+				g.Expect(math.Abs(p.Normal.GetY())).To(BeNumerically("~", 1, base.Epsilon))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			points := SurfacePoints(test.object(), 20, rand.New(rand.NewPCG(1, 2)))
+			g.Expect(points).To(HaveLen(20))
+			for _, p := range points {
+				test.check(g, p)
+			}
+		})
+	}
+}
+
+func TestSurfacePoints_Spread(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// a cube stretched along x has most of its area on the sides facing y and z
+	c := NewCube()
+	c.SetTransform(base.Scale(4, 1, 1))
+	points := SurfacePoints(c, 400, rand.New(rand.NewPCG(1, 2)))
+	g.Expect(points).To(HaveLen(400))
+	var ends int
+	for _, p := range points {
+		g.Expect(p.Normal.Magnitude()).To(BeNumerically("~", 1, base.Epsilon))
+		if math.Abs(p.Normal.GetX()) > 0.5 {
+			ends++
+		}
+	}
+	// the ends are 8 of the cube's 72 units of area
+	g.Expect(ends).To(BeNumerically("~", 400*8/72, 20))
+
+	// the points are repeatable
+	g.Expect(SurfacePoints(c, 400, rand.New(rand.NewPCG(1, 2)))).To(Equal(points))
+
+	// there are no points on an infinite surface
+	g.Expect(SurfacePoints(NewPlane(), 10, rand.New(rand.NewPCG(1, 2)))).To(BeNil())
+}
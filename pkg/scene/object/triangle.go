@@ -57,6 +57,7 @@ func (t *Triangle) DeepCopy() Object {
 	newObj.SetMaterial(&newMaterial)
 	newTransform := t.transform
 	newObj.SetTransform(&newTransform)
+	newObj.copyMotion(t.object)
 
 	return newObj
 }
@@ -68,6 +69,7 @@ func (t *SmoothTriangle) DeepCopy() Object {
 	newObj.SetMaterial(&newMaterial)
 	newTransform := t.transform
 	newObj.SetTransform(&newTransform)
+	newObj.copyMotion(t.object)
 
 	return newObj
 }
@@ -0,0 +1,83 @@
+package scene
+
+import (
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/object"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/ray"
+)
+
+const (
+	// The number of rays sent from each hit for ambient occlusion, if the world has none set.
+	defaultOcclusionSamples = 16
+	// The distance that objects occlude a hit from, if the world has none set.
+	defaultOcclusionRadius = 1.0
+)
+
+// returns the fraction (from 0 to 1) of the hemisphere above the hit that is open, out to the
+// occlusion radius. Rays are sent in directions spread in proportion to the cosine of their angle
+// from the normal, so that nearby objects facing the hit occlude it the most. Objects that don't
+// cast shadows don't occlude the hit.
+func (w *World) occlusionAt(hd *hitData) float64 {
+	samples := w.occlusionSamples
+	if samples <= 0 {
+		samples = defaultOcclusionSamples
+	}
+
+	// the directions are seeded by the point, so that renders are repeatable
+	rng := pointRand(hd.point)
+
+	open := 0
+	for range samples {
+		r := ray.NewRay(hd.overPoint, cosineDirection(hd.normalv, rng.Float64(), rng.Float64()))
+		r.Time = hd.time
+		if !w.occludes(r, w.occlusionRadius) {
+			open++
+		}
+	}
+
+	return float64(open) / float64(samples)
+}
+
+// returns whether an object that casts shadows is hit by the ray within the distance.
+func (w *World) occludes(r *ray.Ray, distance float64) bool {
+	for _, i := range w.intersect(r) {
+		if i.Value <= 0 {
+			continue
+		}
+		if i.Value >= distance {
+			break
+		}
+		if i.Object.GetMaterial().Shadow {
+			return true
+		}
+	}
+
+	return false
+}
+
+// returns the material of the hit with its ambient term scaled by the hit's ambient occlusion,
+// or the object's own material if the world has no ambient occlusion.
+func (w *World) occludedMaterial(hd *hitData) *object.Material {
+	material := hd.object.GetMaterial()
+	if w.occlusionSamples <= 0 || material.Ambient == 0 {
+		return material
+	}
+
+	occluded := *material
+	occluded.Ambient *= w.occlusionAt(hd)
+
+	return &occluded
+}
+
+// returns the gray color of the occlusion pass for a ray, where the open fraction of the hemisphere
+// above the first hit is white, and fully occluded hits are black. Rays that miss are white.
+func (w *World) occlusionColorAt(r *ray.Ray) *image.Color {
+	intersections := w.intersect(r)
+	hit := object.Hit(intersections)
+	if hit == nil {
+		return image.White
+	}
+	open := w.occlusionAt(prepareComputations(hit, r, intersections))
+
+	return image.NewColor(open, open, open)
+}
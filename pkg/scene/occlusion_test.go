@@ -0,0 +1,167 @@
+package scene
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/object"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/ray"
+)
+
+// returns the hit data for a ray pointing straight down at the origin of a floor.
+func floorHit(floor object.Object) *hitData {
+	r := ray.NewRay(base.NewPoint(0, 1, 0), base.NewVector(0, -1, 0))
+	ints := object.Intersections(object.NewIntersection(1, floor))
+
+	return prepareComputations(ints[0], r, ints)
+}
+
+func TestOcclusionAt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		radius   float64
+		objects  func() []object.Object
+		expOpen  float64
+		expRange bool
+	}{
+		{
+			name:    "nothing above the floor",
+			radius:  5,
+			expOpen: 1,
+		},
+		{
+			name:   "inside a box",
+			radius: 5,
+			objects: func() []object.Object {
+				box := object.NewCube()
+				box.SetTransform(base.Scale(2, 2, 2))
+				return []object.Object{box}
+			},
+			expOpen: 0,
+		},
+		{
+			name:   "box is out of the radius",
+			radius: 0.5,
+			objects: func() []object.Object {
+				box := object.NewCube()
+				box.SetTransform(base.Scale(2, 2, 2))
+				return []object.Object{box}
+			},
+			expOpen: 1,
+		},
+		{
+			name:   "box doesn't cast shadows",
+			radius: 5,
+			objects: func() []object.Object {
+				box := object.NewCube()
+				box.SetTransform(base.Scale(2, 2, 2))
+				box.Shadow = false
+				return []object.Object{box}
+			},
+			expOpen: 1,
+		},
+		{
+			name:   "next to a wall",
+			radius: 5,
+			objects: func() []object.Object {
+				wall := object.NewPlane()
+				wall.SetTransform(base.Translate(0.5, 0, 0).Multiply(base.RotateZ(math.Pi / 2)))
+				return []object.Object{wall}
+			},
+			expRange: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			floor := object.NewPlane()
+			objects := []object.Object{floor}
+			if test.objects != nil {
+				objects = append(objects, test.objects()...)
+			}
+			w := NewWorld(nil, objects)
+			w.SetAmbientOcclusion(64, test.radius)
+			hd := floorHit(floor)
+
+			open := w.occlusionAt(hd)
+			if test.expRange {
+				g.Expect(open).To(And(BeNumerically(">", 0.2), BeNumerically("<", 0.8)))
+			} else {
+				g.Expect(open).To(Equal(test.expOpen))
+			}
+			// the same hit is always occluded the same
+			g.Expect(w.occlusionAt(hd)).To(Equal(open))
+		})
+	}
+}
+
+func TestSetAmbientOcclusion(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	floor := object.NewPlane()
+	floor.Ambient = 0.5
+	box := object.NewCube()
+	box.SetTransform(base.Scale(2, 2, 2))
+	light := NewPointLight(base.NewPoint(0, 1, 0), image.White)
+	w := NewWorld([]Light{light}, []object.Object{floor, box})
+	hd := floorHit(floor)
+
+	// without ambient occlusion, the floor's material is used as is
+	g.Expect(w.occlusionSamples).To(Equal(0))
+	g.Expect(w.occludedMaterial(hd)).To(BeIdenticalTo(floor.GetMaterial()))
+	lit := w.shadeHit(hd, remainingReflections)
+
+	// the box blocks the whole hemisphere, so there's no ambient light left
+	w.SetAmbientOcclusion(8, 0)
+	g.Expect(w.occlusionRadius).To(Equal(defaultOcclusionRadius))
+	w.SetAmbientOcclusion(8, 5)
+	g.Expect(w.occlusionRadius).To(Equal(5.0))
+	g.Expect(w.occludedMaterial(hd).Ambient).To(Equal(0.0))
+	g.Expect(floor.Ambient).To(Equal(0.5))
+	g.Expect(w.shadeHit(hd, remainingReflections).Equals(lit.Subtract(image.NewColor(0.5, 0.5, 0.5)))).To(BeTrue())
+
+	w.SetAmbientOcclusion(-1, 5)
+	g.Expect(w.occlusionSamples).To(Equal(0))
+	g.Expect(w.shadeHit(hd, remainingReflections)).To(Equal(lit))
+}
+
+func TestOcclusionPass(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	worldTestSetup()
+
+	c := NewCamera(11, 11, math.Pi/2)
+	c.SetTransform(base.ViewTransform(base.NewPoint(0, 0, -5), base.Origin, base.NewVector(0, 1, 0)))
+	floor := object.NewPlane()
+	floor.SetTransform(base.Translate(0, -1, 0))
+	objects := append([]object.Object{floor}, testObjects...)
+
+	w := NewWorld(testLights, objects)
+	g.Expect(w.pass).To(Equal(ShadedPass))
+	shaded := Render(c, w)
+
+	// the pass uses the default number of samples, without darkening the shaded pass
+	w.SetAmbientOcclusion(0, 5)
+	g.Expect(Render(c, w)).To(Equal(shaded))
+	w.SetPass(OcclusionPass)
+	occlusion := Render(c, w)
+	g.Expect(occlusion).ToNot(Equal(shaded))
+	g.Expect(Render(c, w)).To(Equal(occlusion))
+
+	// rays that miss everything are white, and the bottom of the sphere is darkened by the floor
+	g.Expect(occlusion.PixelAt(5, 0)).To(Equal(image.White))
+	red, green, blue := occlusion.PixelAt(5, 6).RGB()
+	g.Expect(red).To(BeNumerically("<", 0.5))
+	g.Expect(red).To(Equal(green))
+	g.Expect(green).To(Equal(blue))
+}
@@ -0,0 +1,185 @@
+package scene
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/object"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/ray"
+)
+
+const (
+	// The number of bounces a path always makes before Russian roulette can end it.
+	minPathBounces = 3
+	// The most bounces a path can make, if the world has no max depth, in case Russian roulette keeps it going.
+	maxPathBounces = 64
+	// The highest chance of a path surviving Russian roulette, so that even the brightest paths end.
+	maxSurvival = 0.95
+)
+
+// scatterKind is the way that a path leaves a surface.
+type scatterKind int
+
+const (
+	// in a random direction over the hemisphere around the normal
+	diffuseScatter scatterKind = iota
+	// in a direction spread around the mirror direction, such as off a rough metal
+	glossyScatter
+	// in the one mirror or refracted direction
+	specularScatter
+)
+
+// returns the color of a ray using Monte Carlo path tracing. At each surface the path hits, the
+// light reaching the surface directly is gathered, and the path continues in a single diffuse,
+// reflected, or refracted direction, picked at random in proportion to the surface's material.
+// Averaged over many paths, this includes the light bounced between diffuse surfaces, which
+// replaces the material's flat ambient term.
+func (w *World) pathColorAt(r *ray.Ray, rng *rand.Rand) *image.Color {
+	color := image.Black
+	// the fraction of the light at the current surface that is carried back along the path
+	throughput := image.White
+	// how the path left the last surface, where the camera's ray sees everything as a mirror would
+	kind := specularScatter
+	for bounce := range w.depth(maxPathBounces) {
+		intersections := w.intersect(r)
+		hit := object.Hit(intersections)
+		if hit == nil {
+			added, transmittance := w.mediumAlong(r, math.Inf(1), nil)
+			color = color.Add(added.MultiplyColor(throughput))
+			// the background only lights diffuse surfaces if the world says so, but it's always seen
+			// directly, and in reflections and refractions
+			if transmittance > 0 && (kind != diffuseScatter || w.backgroundLighting) {
+				color = color.Add(w.backgroundAt(r).MultiplyColor(throughput).Multiply(transmittance))
+			}
+			break
+		}
+		hd := prepareComputations(hit, r, intersections)
+		hd.rng = rng
+		added, transmittance := w.mediumAlong(r, hd.value, hd.medium)
+		color = color.Add(added.MultiplyColor(throughput))
+		throughput = throughput.Multiply(transmittance)
+		color = color.Add(w.directLight(hd).MultiplyColor(throughput))
+		// an object that lights the world was already counted in the direct light at the last surface
+		// (including its highlights), unless the path reached it in a mirror reflection or refraction
+		if emission := hd.object.GetMaterial().Emission; emission != nil {
+			if kind == specularScatter || !w.isLight(hd.object) {
+				color = color.Add(emission.MultiplyColor(throughput))
+			}
+		}
+
+		var weight *image.Color
+		r, weight, kind = scatter(hd, rng)
+		if r == nil {
+			break
+		}
+		throughput = throughput.MultiplyColor(weight)
+
+		// Russian roulette ends dim paths early, and brightens the surviving paths to make up for them
+		if bounce >= minPathBounces {
+			survival := min(max(throughput.RGB()), maxSurvival)
+			if rng.Float64() >= survival {
+				break
+			}
+			throughput = throughput.Multiply(1 / survival)
+		}
+	}
+
+	return color
+}
+
+// returns the light that reaches the hit directly from every light in the world, from the material's
+// shader without the material's ambient term.
+func (w *World) directLight(hd *hitData) *image.Color {
+	material := *hd.object.GetMaterial()
+	material.Ambient = 0
+
+	// shaders can't trace more rays, since the path continues on its own
+	return shaderFor(&material).Shade(w.newHit(hd, &material, 0))
+}
+
+// returns whether the object is part of one of the world's geometry lights.
+func (w *World) isLight(obj object.Object) bool {
+	for _, light := range w.lights {
+		if geometryLight, ok := light.(*GeometryLight); ok && geometryLight.contains(obj) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// returns the ray that a path continues along from the hit, and the fraction of the light from
+// that ray that is carried back along the path. The direction is picked in proportion to the
+// material's diffuse, reflective, and transparency values (with reflective and transparent surfaces
+// split by the Fresnel effect, as in the Whitted integrator). If those values add up to more than 1,
+// they are scaled down, since a surface can't give back more light than it receives.
+// Also returns how the ray left the surface, or a nil ray if the path ends at the hit.
+func scatter(hd *hitData, rng *rand.Rand) (*ray.Ray, *image.Color, scatterKind) {
+	material := hd.object.GetMaterial()
+	if material.Shader == MicrofacetShader {
+		return scatterMicrofacet(hd, material, rng)
+	}
+	reflective, transparency := material.Reflective, material.Transparency
+	if reflective > 0 && transparency > 0 {
+		reflectance := schlick(hd)
+		reflective *= reflectance
+		transparency *= 1 - reflectance
+	}
+	total := material.Diffuse + reflective + transparency
+	if total <= 0 {
+		return nil, nil, specularScatter
+	}
+
+	origin, direction := hd.overPoint, hd.reflectv
+	tint := image.White
+	kind := specularScatter
+	switch choice := rng.Float64() * total; {
+	case choice < reflective:
+		// the reflected ray
+	case choice < reflective+transparency:
+		origin, direction = hd.underPoint, refractionDirection(hd)
+		if direction == nil {
+			// total internal reflection
+			return nil, nil, specularScatter
+		}
+	default:
+		// the cosine weighted directions match how much light a diffuse surface reflects
+		// in each direction, so only the surface's color tints the light
+		direction = cosineDirection(hd.normalv, rng.Float64(), rng.Float64())
+		tint = surfaceColor(hd.object, material, hd.point, hd.time)
+		kind = diffuseScatter
+	}
+
+	next := ray.NewRay(origin, direction)
+	next.Time = hd.time
+
+	return next, tint.Multiply(min(total, 1)), kind
+}
+
+// returns the direction on the hemisphere around the normal for the u and v fractions (from 0 to 1),
+// where evenly spread u and v values give directions spread in proportion to the cosine of their
+// angle from the normal.
+func cosineDirection(normal *base.Tuple, u, v float64) *base.Tuple {
+	tangent, bitangent := tangents(normal)
+
+	// a point evenly spread over the unit disk, projected up onto the hemisphere
+	radius := math.Sqrt(u)
+	theta := 2 * math.Pi * v
+
+	return tangent.Multiply(radius * math.Cos(theta)).
+		Add(bitangent.Multiply(radius * math.Sin(theta))).
+		Add(normal.Multiply(math.Sqrt(1 - u)))
+}
+
+// returns two unit vectors perpendicular to the normal and to each other.
+func tangents(normal *base.Tuple) (*base.Tuple, *base.Tuple) {
+	helper := base.NewVector(1, 0, 0)
+	if math.Abs(normal.GetX()) > 0.9 {
+		helper = base.NewVector(0, 1, 0)
+	}
+	tangent := normal.CrossProduct(helper).Normalize()
+
+	return tangent, normal.CrossProduct(tangent)
+}
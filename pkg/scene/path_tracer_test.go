@@ -0,0 +1,260 @@
+package scene
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/object"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/ray"
+)
+
+func TestCosineDirection(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		normal *base.Tuple
+	}{
+		{
+			name:   "up",
+			normal: base.NewVector(0, 1, 0),
+		},
+		{
+			name:   "along x",
+			normal: base.NewVector(-1, 0, 0),
+		},
+		{
+			name:   "diagonal",
+			normal: base.NewVector(1, 1, 1).Normalize(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			// the center of the disk is the normal, and the edge is perpendicular to it
+			g.Expect(cosineDirection(test.normal, 0, 0.3).Equals(test.normal)).To(BeTrue())
+			g.Expect(cosineDirection(test.normal, 1, 0.3).DotProduct(test.normal)).To(BeNumerically("~", 0, base.Epsilon))
+
+			for _, u := range []float64{0.1, 0.5, 0.9} {
+				for _, v := range []float64{0, 0.25, 0.6} {
+					direction := cosineDirection(test.normal, u, v)
+					g.Expect(direction.Magnitude()).To(BeNumerically("~", 1, base.Epsilon))
+					// the cosine of the angle from the normal
+					g.Expect(direction.DotProduct(test.normal)).To(BeNumerically("~", math.Sqrt(1-u), base.Epsilon))
+				}
+			}
+		})
+	}
+}
+
+func TestScatter(t *testing.T) {
+	t.Parallel()
+
+	r := ray.NewRay(base.NewPoint(0, 1, -1), base.NewVector(0, -math.Sqrt(2)/2, math.Sqrt(2)/2))
+	tests := []struct {
+		name      string
+		material  func(*object.Material)
+		direction *base.Tuple // nil for a random diffuse direction
+		weight    *image.Color
+		under     bool
+	}{
+		{
+			name: "diffuse",
+			material: func(m *object.Material) {
+				m.Color = image.NewColor(1, 0.5, 0)
+				m.Diffuse = 0.8
+			},
+			weight: image.NewColor(0.8, 0.4, 0),
+		},
+		{
+			name: "mirror",
+			material: func(m *object.Material) {
+				m.Diffuse = 0
+				m.Reflective = 1
+			},
+			direction: base.NewVector(0, math.Sqrt(2)/2, math.Sqrt(2)/2),
+			weight:    image.White,
+		},
+		{
+			name: "glass",
+			material: func(m *object.Material) {
+				m.Diffuse = 0
+				m.Transparency = 0.5
+				m.RefractiveIndex = 1
+			},
+			direction: base.NewVector(0, -math.Sqrt(2)/2, math.Sqrt(2)/2),
+			weight:    image.NewColor(0.5, 0.5, 0.5),
+			under:     true,
+		},
+		{
+			name: "brighter than the light it receives",
+			material: func(m *object.Material) {
+				m.Diffuse = 0
+				m.Reflective = 1.5
+			},
+			direction: base.NewVector(0, math.Sqrt(2)/2, math.Sqrt(2)/2),
+			weight:    image.White,
+		},
+		{
+			name: "black",
+			material: func(m *object.Material) {
+				m.Diffuse = 0
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			floor := object.NewPlane()
+			test.material(floor.GetMaterial())
+			ints := object.Intersections(object.NewIntersection(math.Sqrt(2), floor))
+			hd := prepareComputations(ints[0], r, ints)
+
+			rng := rand.New(rand.NewPCG(1, 2))
+			for range 10 {
+				next, weight, kind := scatter(hd, rng)
+				if test.weight == nil {
+					g.Expect(next).To(BeNil())
+					continue
+				}
+				g.Expect(weight.Equals(test.weight)).To(BeTrue())
+				if test.under {
+					g.Expect(next.Origin).To(Equal(hd.underPoint))
+				} else {
+					g.Expect(next.Origin).To(Equal(hd.overPoint))
+				}
+				if test.direction == nil {
+					g.Expect(kind).To(Equal(diffuseScatter))
+					g.Expect(next.Direction.GetY()).To(BeNumerically(">", 0))
+				} else {
+					g.Expect(kind).To(Equal(specularScatter))
+					g.Expect(next.Direction.Equals(test.direction)).To(BeTrue())
+				}
+			}
+		})
+	}
+}
+
+func TestPathColorAt(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	worldTestSetup()
+
+	// a ray that misses everything is black
+	w := NewWorld(testLights, testObjects)
+	rng := rand.New(rand.NewPCG(1, 2))
+	r := ray.NewRay(base.NewPoint(0, 0, -5), base.NewVector(0, 1, 0))
+	g.Expect(w.pathColorAt(r, rng)).To(Equal(image.Black))
+
+	// light that bounces off the outside of a sphere never comes back, so only the
+	// direct light (without the ambient term) is left
+	r = ray.NewRay(base.NewPoint(0, 0, -5), base.NewVector(0, 0, 1))
+	for range 10 {
+		color := w.pathColorAt(r, rng)
+		g.Expect(color.Equals(image.NewColor(0.30066, 0.37583, 0.22550))).To(BeTrue())
+	}
+}
+
+func TestPathColorAt_Interreflection(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// a point on the floor is in the shadow of a roof, next to a lit wall
+	floor := object.NewPlane()
+	wall := object.NewPlane()
+	wall.SetTransform(base.Translate(1.5, 0, 0).Multiply(base.RotateZ(math.Pi / 2)))
+	roof := object.NewCube()
+	roof.SetTransform(base.Translate(0, 3, 0).Multiply(base.Scale(1, 0.1, 1)))
+	light := NewPointLight(base.NewPoint(0, 10, 0), image.White)
+	w := NewWorld([]Light{light}, []object.Object{floor, wall, roof})
+
+	r := ray.NewRay(base.NewPoint(-2, 1, 0), base.NewVector(2, -1, 0).Normalize())
+	g.Expect(w.ColorAt(r, remainingReflections).Equals(image.NewColor(0.1, 0.1, 0.1))).To(BeTrue())
+
+	// light bounced off the wall and the lit floor reaches the point
+	rng := rand.New(rand.NewPCG(1, 2))
+	total := image.Black
+	for range 200 {
+		total = total.Add(w.pathColorAt(r, rng))
+	}
+	average := total.Multiply(1.0 / 200)
+	g.Expect(average.Luminance()).To(BeNumerically(">", 0.05))
+	red, green, blue := average.RGB()
+	g.Expect(red).To(Equal(green))
+	g.Expect(green).To(Equal(blue))
+
+	// a path without any bounces only has the direct light, and the point is in shadow
+	w.SetMaxDepth(1)
+	for range 10 {
+		g.Expect(w.pathColorAt(r, rng)).To(Equal(image.Black))
+	}
+}
+
+func TestPathColorAt_Background(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	floor := object.NewPlane()
+	w := NewWorld(nil, []object.Object{floor})
+	w.SetBackground(NewSolidBackground(image.NewColor(0.5, 0.5, 1)), false)
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	// the background is seen directly
+	r := ray.NewRay(base.NewPoint(0, 1, 0), base.NewVector(0, 1, 0))
+	g.Expect(w.pathColorAt(r, rng).Equals(image.NewColor(0.5, 0.5, 1))).To(BeTrue())
+
+	// without any lights, the floor is black unless the background lights it
+	r = ray.NewRay(base.NewPoint(0, 1, 0), base.NewVector(0, -1, 0))
+	for range 10 {
+		g.Expect(w.pathColorAt(r, rng)).To(Equal(image.Black))
+	}
+	w.SetBackground(NewSolidBackground(image.NewColor(0.5, 0.5, 1)), true)
+	for range 10 {
+		// each path bounces once off the floor, and escapes to the sky
+		g.Expect(w.pathColorAt(r, rng).Equals(image.NewColor(0.45, 0.45, 0.9))).To(BeTrue())
+	}
+
+	// fog hides the background, and fades the floor's light on the way back
+	w.SetFog(0.5, image.NewColor(0.2, 0.2, 0.2))
+	up := ray.NewRay(base.NewPoint(0, 1, 0), base.NewVector(0, 1, 0))
+	g.Expect(w.pathColorAt(up, rng)).To(Equal(image.NewColor(0.2, 0.2, 0.2)))
+	fog := image.NewColor(0.2, 0.2, 0.2).Multiply(1 - math.Exp(-0.5))
+	for range 10 {
+		// the path escapes through the fog after the floor
+		floorLight := image.NewColor(0.2, 0.2, 0.2).Multiply(0.9).Multiply(math.Exp(-0.5))
+		g.Expect(w.pathColorAt(r, rng).Equals(fog.Add(floorLight))).To(BeTrue())
+	}
+}
+
+func TestPathIntegrator(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	worldTestSetup()
+
+	c := NewCamera(11, 11, math.Pi/2)
+	c.SetTransform(base.ViewTransform(base.NewPoint(0, 0, -5), base.Origin, base.NewVector(0, 1, 0)))
+	floor := object.NewPlane()
+	floor.SetTransform(base.Translate(0, -1, 0))
+	objects := append([]object.Object{floor}, testObjects...)
+
+	w := NewWorld(testLights, objects)
+	g.Expect(w.integrator).To(Equal(WhittedIntegrator))
+	whitted := Render(c, w)
+
+	w.SetIntegrator(PathIntegrator)
+	path := Render(c, w)
+	g.Expect(path).ToNot(Equal(whitted))
+	// renders are repeatable
+	g.Expect(Render(c, w)).To(Equal(path))
+}
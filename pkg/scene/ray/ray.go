@@ -2,10 +2,12 @@ package ray
 
 import "github.com/sjberman/golang-ray-tracer/pkg/base"
 
-// Ray is a light ray with an origin and direction.
+// Ray is a light ray with an origin and direction, sent at a time within the camera's
+// shutter interval (used for motion blur).
 type Ray struct {
 	Origin    *base.Tuple
 	Direction *base.Tuple
+	Time      float64
 }
 
 // NewRay returns a new Ray object.
@@ -23,5 +25,8 @@ func (r *Ray) Position(distance float64) *base.Tuple {
 
 // Transform applies the transformation matrix to the ray.
 func (r *Ray) Transform(matrix *base.Matrix) *Ray {
-	return NewRay(matrix.MultiplyTuple(r.Origin), matrix.MultiplyTuple(r.Direction))
+	transformed := NewRay(matrix.MultiplyTuple(r.Origin), matrix.MultiplyTuple(r.Direction))
+	transformed.Time = r.Time
+
+	return transformed
 }
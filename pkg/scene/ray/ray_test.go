@@ -52,3 +52,12 @@ func TestTransform(t *testing.T) {
 	g.Expect(r2.Origin).To(Equal(base.NewPoint(2, 6, 12)))
 	g.Expect(r2.Direction).To(Equal(base.NewVector(0, 3, 0)))
 }
+
+func TestTransform_KeepsTime(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	r := NewRay(base.NewPoint(1, 2, 3), base.NewVector(0, 1, 0))
+	r.Time = 0.4
+	g.Expect(r.Transform(base.Translate(3, 4, 5)).Time).To(Equal(0.4))
+}
@@ -0,0 +1,407 @@
+package scene
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+)
+
+// Orders that the tiles of a canvas are rendered in.
+const (
+	ScanlineOrder = "scanline" // left to right, top to bottom
+	SpiralOrder   = "spiral"   // outwards from the center of the canvas
+)
+
+// DefaultTileSize is the width and height (in pixels) of a tile if none is given.
+const DefaultTileSize = 16
+
+// RenderOptions determine how the canvas is divided into tiles, and how the tiles are rendered.
+// Zero values use the defaults.
+type RenderOptions struct {
+	Workers  int    // number of tiles rendered at once (default is the number of CPUs)
+	TileSize int    // width and height of a tile in pixels (default DefaultTileSize)
+	Order    string // order that tiles are started in (default ScanlineOrder)
+	Crop     Crop   // region of the canvas that is rendered (default is the whole canvas)
+	// called after each tile is finished (default none). Calls are never concurrent.
+	Progress func(Progress)
+}
+
+// Crop is a rectangle of pixels, from (X0, Y0) up to, but not including, (X1, Y1). The zero value
+// is the whole canvas.
+type Crop struct {
+	X0, Y0, X1, Y1 int
+}
+
+// NormalizedCrop returns the crop of a canvas of the given size, with the corners given as fractions
+// (from 0 to 1) of the width and height. The crop covers every pixel that the rectangle touches.
+func NormalizedCrop(width, height int, x0, y0, x1, y1 float64) Crop {
+	return Crop{
+		X0: int(math.Floor(x0 * float64(width))),
+		Y0: int(math.Floor(y0 * float64(height))),
+		X1: int(math.Ceil(x1 * float64(width))),
+		Y1: int(math.Ceil(y1 * float64(height))),
+	}
+}
+
+// returns the part of the crop within a canvas of the given size, or the whole canvas for the zero value.
+func (c Crop) within(width, height int) Crop {
+	if c == (Crop{}) {
+		return Crop{X1: width, Y1: height}
+	}
+
+	return Crop{
+		X0: max(c.X0, 0),
+		Y0: max(c.Y0, 0),
+		X1: min(c.X1, width),
+		Y1: min(c.Y1, height),
+	}
+}
+
+// Progress reports how much of a render is finished.
+type Progress struct {
+	TilesDone  int
+	TotalTiles int
+	Elapsed    time.Duration
+	Remaining  time.Duration // estimated from the average time per tile so far
+}
+
+// progressTracker counts finished tiles, and reports the progress to a callback.
+type progressTracker struct {
+	mu       sync.Mutex
+	start    time.Time
+	done     int
+	total    int
+	callback func(Progress)
+}
+
+func newProgressTracker(total int, callback func(Progress)) *progressTracker {
+	return &progressTracker{
+		start:    time.Now(),
+		total:    total,
+		callback: callback,
+	}
+}
+
+// TileCounter returns a function to call as each of the total tiles of a render finishes, which
+// reports the progress to the callback. It is safe for concurrent use, and does nothing if the
+// callback is nil.
+func TileCounter(total int, callback func(Progress)) func() {
+	return newProgressTracker(total, callback).tileDone
+}
+
+// records a finished tile, and reports the progress.
+func (p *progressTracker) tileDone() {
+	if p.callback == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done++
+	elapsed := time.Since(p.start)
+	remaining := time.Duration(float64(elapsed) / float64(p.done) * float64(p.total-p.done))
+	p.callback(Progress{
+		TilesDone:  p.done,
+		TotalTiles: p.total,
+		Elapsed:    elapsed,
+		Remaining:  remaining,
+	})
+}
+
+// returns the options with any zero values replaced by the defaults.
+func (o RenderOptions) withDefaults() RenderOptions {
+	if o.Workers < 1 {
+		o.Workers = runtime.NumCPU()
+	}
+	if o.TileSize < 1 {
+		o.TileSize = DefaultTileSize
+	}
+	if o.Order == "" {
+		o.Order = ScanlineOrder
+	}
+
+	return o
+}
+
+// tile is a rectangle of pixels on the canvas, from (x0, y0) up to, but not including, (x1, y1).
+type tile struct {
+	x0, y0, x1, y1 int
+}
+
+// Tiles returns the crops of the tiles covering the cropped region of a canvas of the given size,
+// in the order of the options (using the defaults for any zero values).
+func (o RenderOptions) Tiles(width, height int) []Crop {
+	tiles := o.withDefaults().tiles(width, height)
+	crops := make([]Crop, 0, len(tiles))
+	for _, t := range tiles {
+		crops = append(crops, Crop{X0: t.x0, Y0: t.y0, X1: t.x1, Y1: t.y1})
+	}
+
+	return crops
+}
+
+// returns the tiles covering the cropped region of a canvas of the given size, in the order of the options.
+func (o RenderOptions) tiles(width, height int) []tile {
+	region := o.Crop.within(width, height)
+	tiles := []tile{}
+	for y := region.Y0; y < region.Y1; y += o.TileSize {
+		for x := region.X0; x < region.X1; x += o.TileSize {
+			tiles = append(tiles, tile{
+				x0: x,
+				y0: y,
+				x1: min(x+o.TileSize, region.X1),
+				y1: min(y+o.TileSize, region.Y1),
+			})
+		}
+	}
+
+	if o.Order == SpiralOrder {
+		// sort by the ring of tiles around the center, and then by angle within the ring
+		centerX, centerY := float64(region.X0+region.X1)/2, float64(region.Y0+region.Y1)/2
+		size := float64(o.TileSize)
+		ring := func(t tile) float64 {
+			dx := math.Floor((float64(t.x0) - centerX + size/2) / size)
+			dy := math.Floor((float64(t.y0) - centerY + size/2) / size)
+
+			return math.Max(math.Abs(dx), math.Abs(dy))
+		}
+		angle := func(t tile) float64 {
+			return math.Atan2(float64(t.y0+t.y1)/2-centerY, float64(t.x0+t.x1)/2-centerX)
+		}
+		sort.SliceStable(tiles, func(i, j int) bool {
+			ri, rj := ring(tiles[i]), ring(tiles[j])
+			if ri != rj {
+				return ri < rj
+			}
+
+			return angle(tiles[i]) < angle(tiles[j])
+		})
+	}
+
+	return tiles
+}
+
+// calls the function for each tile, using a pool of workers that take the tiles in order.
+// Returns once every tile is finished, or once the started tiles are finished after the
+// context is cancelled, in which case the context's error is returned.
+func forEachTile(ctx context.Context, tiles []tile, workers int, progress *progressTracker, tileFunc func(tile)) error {
+	queue := make(chan tile, len(tiles))
+	for _, t := range tiles {
+		queue <- t
+	}
+	close(queue)
+
+	var finished atomic.Int64
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for range workers {
+		go func() {
+			defer wg.Done()
+			for t := range queue {
+				if ctx.Err() != nil {
+					return
+				}
+				tileFunc(t)
+				finished.Add(1)
+				progress.tileDone()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if int(finished.Load()) < len(tiles) {
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// Render renders the world onto a canvas using the default options.
+func Render(c *Camera, w *World) *image.Canvas {
+	return RenderWithOptions(c, w, RenderOptions{})
+}
+
+// RenderWithOptions renders the world onto a canvas, one tile at a time. Every pixel's color only
+// depends on its location, so the result is the same for any number of workers, tile size, or order.
+func RenderWithOptions(c *Camera, w *World, opts RenderOptions) *image.Canvas {
+	canvas, _ := RenderContext(context.Background(), c, w, opts)
+
+	return canvas
+}
+
+// RenderContext renders the world onto a canvas like RenderWithOptions, but stops starting new tiles
+// once the context is done. If the render is stopped early, it returns the partially rendered canvas
+// (with unrendered tiles left black) and the context's error.
+func RenderContext(ctx context.Context, c *Camera, w *World, opts RenderOptions) (*image.Canvas, error) {
+	canvas := image.NewCanvas(c.hsize, c.vsize)
+	err := renderTiles(ctx, c, w, opts, canvas, 0, 0)
+
+	return canvas, err
+}
+
+// RenderCrop renders only the crop of the options, like RenderContext, onto a canvas the size of
+// the crop. This is useful for rendering a piece of a large image on its own.
+func RenderCrop(ctx context.Context, c *Camera, w *World, opts RenderOptions) (*image.Canvas, error) {
+	region := opts.Crop.within(c.hsize, c.vsize)
+	canvas := image.NewCanvas(max(region.X1-region.X0, 0), max(region.Y1-region.Y0, 0))
+	err := renderTiles(ctx, c, w, opts, canvas, region.X0, region.Y0)
+
+	return canvas, err
+}
+
+// renders the tiles of the options onto the canvas, where the canvas's top left pixel is at
+// the offset in the camera's image.
+func renderTiles(
+	ctx context.Context,
+	c *Camera,
+	w *World,
+	opts RenderOptions,
+	canvas *image.Canvas,
+	offsetX, offsetY int,
+) error {
+	opts = opts.withDefaults()
+	tiles := opts.tiles(c.hsize, c.vsize)
+	progress := newProgressTracker(len(tiles), opts.Progress)
+
+	return forEachTile(ctx, tiles, opts.Workers, progress, func(t tile) {
+		for y := t.y0; y < t.y1; y++ {
+			for x := t.x0; x < t.x1; x++ {
+				canvas.WritePixel(x-offsetX, y-offsetY, w.colorAtPixel(c, x, y))
+			}
+		}
+	})
+}
+
+// ProgressiveOptions determine the passes of a progressive render, and when snapshots are taken.
+type ProgressiveOptions struct {
+	RenderOptions
+	Passes   int           // number of samples added to every pixel, one per pass (default 1)
+	Interval time.Duration // time between snapshots (default 0, no snapshots)
+	// called every interval with the average of the samples so far. Calls are never concurrent.
+	Snapshot func(*image.Canvas)
+}
+
+// RenderProgressive renders the world in passes, where each pass adds a sample to every pixel and
+// the canvas is the running average of the samples. The first pass is through the center of each
+// pixel for a quick preview, and later passes are at random points in the pixel (and on the lens and
+// in the shutter interval), so the image refines over time. The camera's samples are not used. If the
+// context is done, the passes stop early and the average so far is returned with the context's error.
+func RenderProgressive(ctx context.Context, c *Camera, w *World, opts ProgressiveOptions) (*image.Canvas, error) {
+	renderOpts := opts.RenderOptions.withDefaults()
+	passes := max(opts.Passes, 1)
+	tiles := renderOpts.tiles(c.hsize, c.vsize)
+	progress := newProgressTracker(passes*len(tiles), renderOpts.Progress)
+	accumulator := image.NewAccumulator(c.hsize, c.vsize)
+
+	stopSnapshots := takeSnapshots(accumulator, opts.Interval, opts.Snapshot)
+	var err error
+	for pass := range passes {
+		err = forEachTile(ctx, tiles, renderOpts.Workers, progress, func(t tile) {
+			// the tile's samples are added to the accumulator together
+			samples := image.NewCanvas(t.x1-t.x0, t.y1-t.y0)
+			for y := t.y0; y < t.y1; y++ {
+				for x := t.x0; x < t.x1; x++ {
+					samples.WritePixel(x-t.x0, y-t.y0, w.colorAtSample(c, x, y, c.passSample(x, y, pass)))
+				}
+			}
+			accumulator.AddSamples(t.x0, t.y0, samples)
+		})
+		if err != nil {
+			break
+		}
+	}
+	stopSnapshots()
+
+	return accumulator.Canvas(), err
+}
+
+// calls the snapshot function with the accumulated canvas every interval, until the returned
+// function is called.
+func takeSnapshots(accumulator *image.Accumulator, interval time.Duration, snapshot func(*image.Canvas)) func() {
+	if interval <= 0 || snapshot == nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				snapshot(accumulator.Canvas())
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// RenderAdaptive renders the world with a single ray through each pixel, and then re-renders
+// the pixels that differ from a neighboring pixel by more than the threshold, using the camera's
+// anti-aliasing samples. It returns the canvas and the number of pixels that were refined. Progress
+// counts each tile twice, once for each pass.
+func RenderAdaptive(c *Camera, w *World, threshold float64, opts RenderOptions) (*image.Canvas, int) {
+	opts = opts.withDefaults()
+	tiles := opts.tiles(c.hsize, c.vsize)
+	canvas := image.NewCanvas(c.hsize, c.vsize)
+	progress := newProgressTracker(2*len(tiles), opts.Progress)
+	// never cancelled, so every tile is rendered
+	ctx := context.Background()
+
+	// first pass, through the center of each pixel
+	_ = forEachTile(ctx, tiles, opts.Workers, progress, func(t tile) {
+		for y := t.y0; y < t.y1; y++ {
+			for x := t.x0; x < t.x1; x++ {
+				canvas.WritePixel(x, y, w.colorAtSample(c, x, y, c.centerSample(x, y)))
+			}
+		}
+	})
+
+	// find the pixels with high contrast to their neighbors in the rendered region
+	region := opts.Crop.within(c.hsize, c.vsize)
+	refine := make([][]bool, c.vsize)
+	for y := range refine {
+		refine[y] = make([]bool, c.hsize)
+	}
+	for y := region.Y0; y < region.Y1; y++ {
+		for x := region.X0; x < region.X1; x++ {
+			if x+1 < region.X1 && canvas.PixelAt(x, y).Difference(canvas.PixelAt(x+1, y)) > threshold {
+				refine[y][x], refine[y][x+1] = true, true
+			}
+			if y+1 < region.Y1 && canvas.PixelAt(x, y).Difference(canvas.PixelAt(x, y+1)) > threshold {
+				refine[y][x], refine[y+1][x] = true, true
+			}
+		}
+	}
+
+	// second pass, supersampling only the high contrast pixels
+	var refined atomic.Int64
+	_ = forEachTile(ctx, tiles, opts.Workers, progress, func(t tile) {
+		for y := t.y0; y < t.y1; y++ {
+			for x := t.x0; x < t.x1; x++ {
+				if refine[y][x] {
+					canvas.WritePixel(x, y, w.colorAtPixel(c, x, y))
+					refined.Add(1)
+				}
+			}
+		}
+	})
+
+	return canvas, int(refined.Load())
+}
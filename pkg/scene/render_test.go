@@ -0,0 +1,291 @@
+package scene
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+)
+
+func TestTiles(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// scanline tiles cover the canvas, with smaller tiles at the edges
+	opts := RenderOptions{TileSize: 4}.withDefaults()
+	tiles := opts.tiles(10, 6)
+	g.Expect(tiles).To(Equal([]tile{
+		{x0: 0, y0: 0, x1: 4, y1: 4}, {x0: 4, y0: 0, x1: 8, y1: 4}, {x0: 8, y0: 0, x1: 10, y1: 4},
+		{x0: 0, y0: 4, x1: 4, y1: 6}, {x0: 4, y0: 4, x1: 8, y1: 6}, {x0: 8, y0: 4, x1: 10, y1: 6},
+	}))
+
+	// spiral tiles start at the center, and cover the same tiles
+	opts.Order = SpiralOrder
+	spiral := opts.tiles(12, 12)
+	g.Expect(spiral[0]).To(Equal(tile{x0: 4, y0: 4, x1: 8, y1: 8}))
+	g.Expect(spiral).To(ConsistOf(RenderOptions{TileSize: 4}.withDefaults().tiles(12, 12)))
+
+	// the outer ring of tiles comes after the inner ring
+	spiral = opts.tiles(20, 20)
+	g.Expect(spiral).To(HaveLen(25))
+	for _, tl := range spiral[:9] {
+		g.Expect(tl.x0).To(BeNumerically(">=", 4))
+		g.Expect(tl.x1).To(BeNumerically("<=", 16))
+		g.Expect(tl.y0).To(BeNumerically(">=", 4))
+		g.Expect(tl.y1).To(BeNumerically("<=", 16))
+	}
+
+	// cropped tiles only cover the part of the crop within the canvas
+	opts = RenderOptions{TileSize: 4, Crop: Crop{X0: 3, Y0: 5, X1: 12, Y1: 20}}.withDefaults()
+	g.Expect(opts.tiles(10, 8)).To(Equal([]tile{
+		{x0: 3, y0: 5, x1: 7, y1: 8}, {x0: 7, y0: 5, x1: 10, y1: 8},
+	}))
+	opts.Order = SpiralOrder
+	g.Expect(opts.tiles(10, 8)).To(ConsistOf(tile{x0: 3, y0: 5, x1: 7, y1: 8}, tile{x0: 7, y0: 5, x1: 10, y1: 8}))
+
+	// empty crop
+	opts.Crop = Crop{X0: 5, Y0: 5, X1: 5, Y1: 8}
+	g.Expect(opts.tiles(10, 8)).To(BeEmpty())
+}
+
+func TestRenderOptionsTiles(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	opts := RenderOptions{TileSize: 4, Crop: Crop{X0: 3, Y0: 5, X1: 12, Y1: 20}}
+	g.Expect(opts.Tiles(10, 8)).To(Equal([]Crop{
+		{X0: 3, Y0: 5, X1: 7, Y1: 8}, {X0: 7, Y0: 5, X1: 10, Y1: 8},
+	}))
+
+	// the default tile size covers a small canvas with a single tile
+	g.Expect(RenderOptions{}.Tiles(10, 8)).To(Equal([]Crop{{X1: 10, Y1: 8}}))
+}
+
+func TestNormalizedCrop(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	g.Expect(NormalizedCrop(100, 50, 0, 0, 1, 1)).To(Equal(Crop{X1: 100, Y1: 50}))
+	g.Expect(NormalizedCrop(100, 50, 0.25, 0.5, 0.5, 0.75)).To(Equal(Crop{X0: 25, Y0: 25, X1: 50, Y1: 38}))
+	g.Expect(NormalizedCrop(10, 10, 0.11, 0.19, 0.21, 0.29)).To(Equal(Crop{X0: 1, Y0: 1, X1: 3, Y1: 3}))
+}
+
+func TestTileCounter(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	var reports []Progress
+	tileDone := TileCounter(3, func(p Progress) { reports = append(reports, p) })
+	tileDone()
+	tileDone()
+	g.Expect(reports).To(HaveLen(2))
+	g.Expect(reports[1].TilesDone).To(Equal(2))
+	g.Expect(reports[1].TotalTiles).To(Equal(3))
+
+	// a nil callback is ignored
+	TileCounter(3, nil)()
+}
+
+func TestRenderOptionsDefaults(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	opts := RenderOptions{}.withDefaults()
+	g.Expect(opts.Workers).To(BeNumerically(">", 0))
+	g.Expect(opts.TileSize).To(Equal(DefaultTileSize))
+	g.Expect(opts.Order).To(Equal(ScanlineOrder))
+
+	opts = RenderOptions{Workers: 3, TileSize: 8, Order: SpiralOrder}.withDefaults()
+	g.Expect(opts).To(Equal(RenderOptions{Workers: 3, TileSize: 8, Order: SpiralOrder}))
+}
+
+func TestRenderWithOptions(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	worldTestSetup()
+
+	w := NewWorld(testLights, testObjects)
+	// the sphere covers the middle of the edges of the canvas
+	c := NewCamera(11, 7, math.Pi/8)
+	c.SetTransform(base.ViewTransform(base.NewPoint(0, 0, -5), base.Origin, base.NewVector(0, 1, 0)))
+	c.SetSampling(4, JitteredSampling)
+
+	// every pixel is rendered, including the last row and column
+	expected := image.NewCanvas(11, 7)
+	for y := range 7 {
+		for x := range 11 {
+			expected.WritePixel(x, y, w.colorAtPixel(c, x, y))
+		}
+	}
+	g.Expect(expected.PixelAt(10, 3)).ToNot(Equal(image.Black))
+	g.Expect(expected.PixelAt(5, 6)).ToNot(Equal(image.Black))
+
+	// the same image is rendered for any options
+	for _, opts := range []RenderOptions{
+		{},
+		{Workers: 1, TileSize: 1},
+		{Workers: 3, TileSize: 4, Order: SpiralOrder},
+		{Workers: 16, TileSize: 100},
+	} {
+		g.Expect(RenderWithOptions(c, w, opts)).To(Equal(expected))
+	}
+
+	// only the cropped region is rendered, and the rest is left black
+	crop := Crop{X0: 8, Y0: 2, X1: 11, Y1: 5}
+	canvas := RenderWithOptions(c, w, RenderOptions{TileSize: 2, Crop: crop})
+	for y := range 7 {
+		for x := range 11 {
+			if x >= crop.X0 && x < crop.X1 && y >= crop.Y0 && y < crop.Y1 {
+				g.Expect(canvas.PixelAt(x, y)).To(Equal(expected.PixelAt(x, y)))
+			} else {
+				g.Expect(canvas.PixelAt(x, y)).To(Equal(image.Black))
+			}
+		}
+	}
+	g.Expect(canvas.Crop(8, 2, 11, 5)).To(Equal(expected.Crop(8, 2, 11, 5)))
+}
+
+func TestRenderContext(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	worldTestSetup()
+
+	w := NewWorld(testLights, testObjects)
+	// the sphere covers most of the canvas
+	c := NewCamera(11, 11, math.Pi/8)
+	c.SetTransform(base.ViewTransform(base.NewPoint(0, 0, -5), base.Origin, base.NewVector(0, 1, 0)))
+	expected := Render(c, w)
+
+	// a finished render reports progress for every tile
+	var reports []Progress
+	opts := RenderOptions{TileSize: 4, Progress: func(p Progress) { reports = append(reports, p) }}
+	canvas, err := RenderContext(context.Background(), c, w, opts)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(canvas).To(Equal(expected))
+	g.Expect(reports).To(HaveLen(9))
+	for i, p := range reports {
+		g.Expect(p.TilesDone).To(Equal(i + 1))
+		g.Expect(p.TotalTiles).To(Equal(9))
+		g.Expect(p.Elapsed).To(BeNumerically(">", 0))
+	}
+	g.Expect(reports[8].Remaining).To(BeZero())
+
+	// a cancelled render doesn't start any tiles
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	canvas, err = RenderContext(ctx, c, w, RenderOptions{})
+	g.Expect(err).To(MatchError(context.Canceled))
+	g.Expect(canvas).To(Equal(image.NewCanvas(11, 11)))
+
+	// a render cancelled part way through keeps the finished tiles
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	opts = RenderOptions{
+		Workers:  1,
+		TileSize: 4,
+		Progress: func(p Progress) {
+			if p.TilesDone == 5 {
+				cancel()
+			}
+		},
+	}
+	canvas, err = RenderContext(ctx, c, w, opts)
+	g.Expect(err).To(MatchError(context.Canceled))
+	// the center tile was finished, and the tile below it wasn't
+	g.Expect(canvas.PixelAt(5, 5)).To(Equal(expected.PixelAt(5, 5)))
+	g.Expect(canvas.PixelAt(5, 9)).To(Equal(image.Black))
+	g.Expect(expected.PixelAt(5, 9)).ToNot(Equal(image.Black))
+}
+
+func TestRenderCrop(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	worldTestSetup()
+
+	w := NewWorld(testLights, testObjects)
+	c := NewCamera(11, 11, math.Pi/8)
+	c.SetTransform(base.ViewTransform(base.NewPoint(0, 0, -5), base.Origin, base.NewVector(0, 1, 0)))
+	expected := Render(c, w)
+
+	// the cropped canvas matches the same part of the full image
+	crop := Crop{X0: 2, Y0: 3, X1: 9, Y1: 7}
+	canvas, err := RenderCrop(context.Background(), c, w, RenderOptions{TileSize: 4, Crop: crop})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(canvas).To(Equal(expected.Crop(2, 3, 9, 7)))
+
+	// a crop past the canvas is clamped
+	canvas, err = RenderCrop(context.Background(), c, w, RenderOptions{Crop: Crop{X0: 8, Y0: 8, X1: 20, Y1: 20}})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(canvas).To(Equal(expected.Crop(8, 8, 11, 11)))
+
+	// no crop renders the whole canvas
+	canvas, err = RenderCrop(context.Background(), c, w, RenderOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(canvas).To(Equal(expected))
+}
+
+func TestRenderProgressive(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	worldTestSetup()
+
+	w := NewWorld(testLights, testObjects)
+	c := NewCamera(11, 11, math.Pi/8)
+	c.SetTransform(base.ViewTransform(base.NewPoint(0, 0, -5), base.Origin, base.NewVector(0, 1, 0)))
+
+	// each pixel is the average of a sample from each pass
+	canvas, err := RenderProgressive(context.Background(), c, w, ProgressiveOptions{Passes: 3})
+	g.Expect(err).ToNot(HaveOccurred())
+	for _, pixel := range [][2]int{{0, 0}, {5, 5}, {10, 3}} {
+		x, y := pixel[0], pixel[1]
+		expected := image.Black
+		for pass := range 3 {
+			expected = expected.Add(w.colorAtSample(c, x, y, c.passSample(x, y, pass)))
+		}
+		g.Expect(canvas.PixelAt(x, y).Equals(expected.Multiply(1.0 / 3))).To(BeTrue())
+	}
+
+	// a single pass is through the center of each pixel
+	canvas, err = RenderProgressive(context.Background(), c, w, ProgressiveOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(canvas).To(Equal(Render(c, w)))
+
+	// a cancelled render returns the passes so far, and snapshots are taken while rendering
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mu sync.Mutex
+	var snapshots []*image.Canvas
+	opts := ProgressiveOptions{
+		RenderOptions: RenderOptions{
+			Workers:  1,
+			TileSize: 4,
+			Progress: func(p Progress) {
+				// slow enough for snapshots
+				time.Sleep(2 * time.Millisecond)
+				if p.TilesDone == 9 {
+					cancel()
+				}
+			},
+		},
+		Passes:   100,
+		Interval: time.Millisecond,
+		Snapshot: func(c *image.Canvas) {
+			mu.Lock()
+			defer mu.Unlock()
+			snapshots = append(snapshots, c)
+		},
+	}
+	canvas, err = RenderProgressive(ctx, c, w, opts)
+	g.Expect(err).To(MatchError(context.Canceled))
+	g.Expect(canvas).To(Equal(Render(c, w)))
+
+	mu.Lock()
+	defer mu.Unlock()
+	g.Expect(snapshots).ToNot(BeEmpty())
+}
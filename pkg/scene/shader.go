@@ -0,0 +1,195 @@
+package scene
+
+import (
+	"math/rand/v2"
+	"sync"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/object"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/ray"
+)
+
+// Names of the built-in shaders.
+const (
+	PhongShader      = "phong"      // diffuse and specular highlights, using Diffuse, Specular, and Shininess
+	MicrofacetShader = "microfacet" // physically based GGX/Cook-Torrance, using Metallic and Roughness
+)
+
+// Shader finds the color of the light that leaves the surface at a hit towards the eye. The
+// reflected and refracted light from the material's Reflective and Transparency values is added
+// by the world afterwards.
+type Shader interface {
+	Shade(*Hit) *image.Color
+}
+
+// ShaderFunc is a function that is used as a Shader.
+type ShaderFunc func(*Hit) *image.Color
+
+// Shade calls the function.
+func (f ShaderFunc) Shade(hit *Hit) *image.Color {
+	return f(hit)
+}
+
+var (
+	shadersMu sync.RWMutex
+	shaders   = map[string]Shader{
+		PhongShader: ShaderFunc(func(hit *Hit) *image.Color {
+			return hit.sumLights(lighting)
+		}),
+		MicrofacetShader: ShaderFunc(func(hit *Hit) *image.Color {
+			return hit.sumLights(microfacetLighting)
+		}),
+	}
+)
+
+// RegisterShader makes the shader available to materials by its name, replacing any shader already
+// registered with the name (including the built-in shaders).
+func RegisterShader(name string, shader Shader) {
+	shadersMu.Lock()
+	defer shadersMu.Unlock()
+
+	shaders[name] = shader
+}
+
+// GetShader returns the shader registered with the name, where "" is the Phong shader.
+func GetShader(name string) (Shader, bool) {
+	if name == "" {
+		name = PhongShader
+	}
+	shadersMu.RLock()
+	defer shadersMu.RUnlock()
+	shader, ok := shaders[name]
+
+	return shader, ok
+}
+
+// returns the shader for the material, or the Phong shader if its shader isn't registered.
+func shaderFor(material *object.Material) Shader {
+	if shader, ok := GetShader(material.Shader); ok {
+		return shader
+	}
+	shader, _ := GetShader(PhongShader)
+
+	return shader
+}
+
+// Hit is what a Shader is given about the point where a ray hit an object.
+type Hit struct {
+	Object object.Object
+	// the material to shade with, which can differ from the object's own material (such as when
+	// the ambient term is scaled by ambient occlusion, or left out by the path integrator)
+	Material *object.Material
+	Point    *base.Tuple
+	// the point just above the surface, which rays leaving the surface start from
+	OverPoint *base.Tuple
+	Normal    *base.Tuple // the surface normal, facing the eye
+	Eye       *base.Tuple // the direction towards the eye
+	Time      float64
+	World     *World
+
+	// the number of times that rays can still be reflected or refracted
+	remaining int
+	weight    float64
+	rng       *rand.Rand
+	// the samples of each light from the hit, taken once so that the shadows and shading match
+	samples map[Light][]lightSample
+}
+
+// returns the hit for a shader, with the material to shade with.
+func (w *World) newHit(hd *hitData, material *object.Material, remaining int) *Hit {
+	rng := hd.rng
+	if rng == nil {
+		rng = pointRand(hd.point)
+	}
+
+	return &Hit{
+		Object:    hd.object,
+		Material:  material,
+		Point:     hd.point,
+		OverPoint: hd.overPoint,
+		Normal:    hd.normalv,
+		Eye:       hd.eyev,
+		Time:      hd.time,
+		World:     w,
+		remaining: remaining,
+		weight:    hd.weight,
+		rng:       rng,
+		samples:   map[Light][]lightSample{},
+	}
+}
+
+// Lights returns the lights in the world.
+func (h *Hit) Lights() []Light {
+	return h.World.lights
+}
+
+// Color returns the color of the surface at the hit, from its material's color or pattern.
+func (h *Hit) Color() *image.Color {
+	return surfaceColor(h.Object, h.Material, h.Point, h.Time)
+}
+
+// returns the samples of the light from the hit, which are the same each time for the light.
+func (h *Hit) samplesOf(light Light) []lightSample {
+	samples, ok := h.samples[light]
+	if !ok {
+		samples = light.samplesFrom(h.Point, h.rng)
+		h.samples[light] = samples
+	}
+
+	return samples
+}
+
+// LightReaching returns the fraction (from 0 to 1) of each color of the light that reaches the hit,
+// which is less in shadow.
+func (h *Hit) LightReaching(light Light) *image.Color {
+	return light.intensityAt(h.OverPoint, h.Time, h.World, h.samplesOf(light))
+}
+
+// LightDirections returns the directions from the hit to the samples of the light (a single direction
+// for all but area lights), with the scale of the light's intensity at each sample's distance. These
+// are the same samples that LightReaching finds the shadows from.
+func (h *Hit) LightDirections(light Light) ([]*base.Tuple, []float64) {
+	samples := h.samplesOf(light)
+	directions := make([]*base.Tuple, len(samples))
+	attenuations := make([]float64, len(samples))
+	for i, sample := range samples {
+		directions[i] = sample.direction
+		attenuations[i] = light.attenuationAt(sample.distance)
+	}
+
+	return directions, attenuations
+}
+
+// Trace returns the color seen along a ray sent from the hit, such as a reflection, or black if
+// rays can't be reflected or refracted any more times. Rays leaving the surface should start
+// at the OverPoint.
+func (h *Hit) Trace(r *ray.Ray) *image.Color {
+	if h.remaining < 1 {
+		return image.Black
+	}
+
+	return h.World.colorAt(r, h.remaining-1, h.weight, h.rng)
+}
+
+// lightingFunc returns the color of a point lit by a light, as lighting does.
+type lightingFunc func(
+	light Light,
+	obj object.Object,
+	material *object.Material,
+	point, eyev, normalv *base.Tuple,
+	time float64,
+	samples []lightSample,
+	intensity *image.Color,
+) *image.Color
+
+// returns the sum of the light from each light in the world, using the lighting function.
+func (h *Hit) sumLights(lightingAt lightingFunc) *image.Color {
+	color := image.Black
+	for _, light := range h.Lights() {
+		color = color.Add(lightingAt(
+			light, h.Object, h.Material, h.Point, h.Eye, h.Normal, h.Time, h.samplesOf(light), h.LightReaching(light)))
+	}
+
+	return color
+}
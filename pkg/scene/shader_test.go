@@ -0,0 +1,149 @@
+package scene
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/object"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/ray"
+)
+
+// registers the shader for the test, and removes it once the test is done.
+func registerTestShader(t *testing.T, name string, shader Shader) {
+	t.Helper()
+	RegisterShader(name, shader)
+	t.Cleanup(func() {
+		shadersMu.Lock()
+		defer shadersMu.Unlock()
+		delete(shaders, name)
+	})
+}
+
+func TestGetShader(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		found bool
+	}{
+		{
+			name:  "",
+			found: true,
+		},
+		{
+			name:  PhongShader,
+			found: true,
+		},
+		{
+			name:  MicrofacetShader,
+			found: true,
+		},
+		{
+			name:  "unknown",
+			found: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			shader, found := GetShader(test.name)
+			g.Expect(found).To(Equal(test.found))
+			g.Expect(shader != nil).To(Equal(test.found))
+		})
+	}
+}
+
+func TestRegisterShader(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	light := NewPointLight(base.NewPoint(-10, 10, -10), image.White)
+	sphere := object.NewSphere()
+	sphere.Color = image.NewColor(0.2, 0.4, 0.6)
+	w := NewWorld([]Light{light}, []object.Object{sphere})
+	r := ray.NewRay(base.NewPoint(0, 0, -5), base.NewVector(0, 0, 1))
+	phong := w.ColorAt(r, remainingReflections)
+
+	// a shader that isn't registered falls back to Phong
+	sphere.Shader = "flat"
+	g.Expect(w.ColorAt(r, remainingReflections)).To(Equal(phong))
+
+	var hit *Hit
+	registerTestShader(t, "flat", ShaderFunc(func(h *Hit) *image.Color {
+		hit = h
+		return h.Color()
+	}))
+	g.Expect(w.ColorAt(r, remainingReflections)).To(Equal(image.NewColor(0.2, 0.4, 0.6)))
+	g.Expect(hit.Object).To(Equal(sphere))
+	g.Expect(hit.Material).To(Equal(sphere.GetMaterial()))
+	g.Expect(hit.Point.Equals(base.NewPoint(0, 0, -1))).To(BeTrue())
+	g.Expect(hit.Normal.Equals(base.NewVector(0, 0, -1))).To(BeTrue())
+	g.Expect(hit.Eye.Equals(base.NewVector(0, 0, -1))).To(BeTrue())
+	g.Expect(hit.OverPoint.GetZ()).To(BeNumerically("<", -1))
+	g.Expect(hit.World).To(Equal(w))
+	g.Expect(hit.Lights()).To(Equal([]Light{light}))
+
+	// the path integrator gives the shader the material without its ambient term
+	sphere.Ambient = 0.3
+	g.Expect(w.directLight(prepareComputations(object.Hit(w.intersect(r)), r, w.intersect(r)))).To(
+		Equal(image.NewColor(0.2, 0.4, 0.6)))
+	g.Expect(hit.Material.Ambient).To(Equal(0.0))
+	g.Expect(sphere.Ambient).To(Equal(0.3))
+}
+
+func TestHit_Trace(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// a floor that only shows its mirror reflection, under a glowing roof
+	registerTestShader(t, "test-mirror", ShaderFunc(func(h *Hit) *image.Color {
+		reflectv := h.Eye.Negate().Reflect(h.Normal)
+		return h.Trace(ray.NewRay(h.OverPoint, reflectv))
+	}))
+	floor := object.NewPlane()
+	floor.Shader = "test-mirror"
+	roof := object.NewPlane()
+	roof.SetTransform(base.Translate(0, 5, 0))
+	roof.Color = image.NewColor(1, 0, 0)
+	roof.Ambient = 1
+	roof.Diffuse = 0
+	roof.Specular = 0
+	light := NewPointLight(base.NewPoint(0, 1, 0), image.White)
+	w := NewWorld([]Light{light}, []object.Object{floor, roof})
+
+	r := ray.NewRay(base.NewPoint(0, 1, -1), base.NewVector(0, -math.Sqrt(2)/2, math.Sqrt(2)/2))
+	g.Expect(w.ColorAt(r, remainingReflections)).To(Equal(image.NewColor(1, 0, 0)))
+	// no more rays can be traced
+	g.Expect(w.ColorAt(r, 0)).To(Equal(image.Black))
+}
+
+func TestHit_Lights(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	light := NewPointLight(base.NewPoint(0, 10, 0), image.White)
+	light.SetAttenuation(InverseSquareAttenuation)
+	floor := object.NewPlane()
+	w := NewWorld([]Light{light}, []object.Object{floor})
+	hd := floorHit(floor)
+	hit := w.newHit(hd, floor.GetMaterial(), remainingReflections)
+
+	g.Expect(hit.LightReaching(light)).To(Equal(image.White))
+	directions, attenuations := hit.LightDirections(light)
+	g.Expect(directions).To(HaveLen(1))
+	g.Expect(directions[0].Equals(base.NewVector(0, 1, 0))).To(BeTrue())
+	g.Expect(attenuations).To(Equal([]float64{0.01}))
+
+	// a box over the point shadows it
+	box := object.NewCube()
+	box.SetTransform(base.Translate(0, 3, 0))
+	w.objects = append(w.objects, box)
+	g.Expect(hit.LightReaching(light)).To(Equal(image.Black))
+}
@@ -0,0 +1,63 @@
+package scene
+
+import (
+	"math"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+)
+
+// SpotLight is a point light that only shines within a cone around its direction.
+type SpotLight struct {
+	*PointLight
+	direction *base.Tuple
+	// cosines of the inner and outer cone angles
+	cosInner, cosOuter float64
+}
+
+// NewSpotLight returns a new SpotLight object. The angles (in radians) are measured from the
+// direction of the light; points within the inner angle are fully lit, and the light falls
+// off smoothly to nothing at the outer angle.
+func NewSpotLight(
+	pos, direction *base.Tuple,
+	innerAngle, outerAngle float64,
+	intensity *image.Color,
+) *SpotLight {
+	if innerAngle > outerAngle {
+		innerAngle = outerAngle
+	}
+
+	return &SpotLight{
+		PointLight: NewPointLight(pos, intensity),
+		direction:  direction.Normalize(),
+		cosInner:   math.Cos(innerAngle),
+		cosOuter:   math.Cos(outerAngle),
+	}
+}
+
+// returns the fraction of each color of the light that reaches the point, based on where the point
+// is within the cone and any shadows at the time.
+func (l *SpotLight) intensityAt(point *base.Tuple, time float64, w *World, samples []lightSample) *image.Color {
+	falloff := l.falloff(point)
+	if falloff == 0 {
+		return image.Black
+	}
+
+	return l.PointLight.intensityAt(point, time, w, samples).Multiply(falloff)
+}
+
+// returns how much of the light reaches the point based on its angle from the light's direction.
+func (l *SpotLight) falloff(point *base.Tuple) float64 {
+	cos := point.Subtract(l.position).Normalize().DotProduct(l.direction)
+	if cos >= l.cosInner {
+		return 1
+	}
+	if cos <= l.cosOuter {
+		return 0
+	}
+
+	// smoothstep between the outer and inner cones
+	t := (cos - l.cosOuter) / (l.cosInner - l.cosOuter)
+
+	return t * t * (3 - 2*t)
+}
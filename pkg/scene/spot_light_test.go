@@ -0,0 +1,106 @@
+package scene
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/sjberman/golang-ray-tracer/pkg/base"
+	"github.com/sjberman/golang-ray-tracer/pkg/image"
+	"github.com/sjberman/golang-ray-tracer/pkg/scene/ray"
+)
+
+func TestNewSpotLight(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	pos := base.NewPoint(0, 10, 0)
+	l := NewSpotLight(pos, base.NewVector(0, -2, 0), math.Pi/6, math.Pi/4, image.White)
+	g.Expect(l.position).To(Equal(pos))
+	g.Expect(l.direction).To(Equal(base.NewVector(0, -1, 0)))
+	g.Expect(l.cosInner).To(Equal(math.Cos(math.Pi / 6)))
+	g.Expect(l.cosOuter).To(Equal(math.Cos(math.Pi / 4)))
+	g.Expect(l.GetIntensity()).To(Equal(image.White))
+	g.Expect(l.samplesFrom(base.Origin, testRand())).To(Equal([]lightSample{{direction: base.NewVector(0, 1, 0), distance: 10, position: base.NewPoint(0, 10, 0)}}))
+
+	// inner angle can't be larger than outer angle
+	l = NewSpotLight(pos, base.NewVector(0, -1, 0), math.Pi/3, math.Pi/4, image.White)
+	g.Expect(l.cosInner).To(Equal(l.cosOuter))
+}
+
+func TestSpotLightIntensityAt(t *testing.T) {
+	t.Parallel()
+	worldTestSetup()
+
+	tests := []struct {
+		name         string
+		point        *base.Tuple
+		expIntensity float64
+	}{
+		{
+			name:         "point along the direction of the light",
+			point:        base.NewPoint(0, 1.0001, 0),
+			expIntensity: 1,
+		},
+		{
+			name:         "point within the inner cone",
+			point:        base.NewPoint(4, 0, 0),
+			expIntensity: 1,
+		},
+		{
+			name:         "point between the inner and outer cones",
+			point:        base.NewPoint(7, 0, 0),
+			expIntensity: 0.7909565199140324,
+		},
+		{
+			name:         "point outside the outer cone",
+			point:        base.NewPoint(11, 0, 0),
+			expIntensity: 0,
+		},
+		{
+			name:         "point behind the light",
+			point:        base.NewPoint(0, 20, 0),
+			expIntensity: 0,
+		},
+		{
+			name:         "point within the cone, but in shadow",
+			point:        base.NewPoint(0, -1.0001, 0),
+			expIntensity: 0,
+		},
+	}
+
+	w := NewWorld(testLights, testObjects)
+	l := NewSpotLight(base.NewPoint(0, 10, 0), base.NewVector(0, -1, 0), math.Pi/6, math.Pi/4, image.White)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			g.Expect(reaching(l, test.point, w)).To(Equal(gray(test.expIntensity)))
+		})
+	}
+}
+
+func TestShadeHit_SpotLight(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	worldTestSetup()
+
+	// light pointing away from the hit only contributes ambient light
+	lights := []Light{
+		NewSpotLight(base.NewPoint(-10, 10, -10), base.NewVector(-1, 0, 0), math.Pi/6, math.Pi/4, image.White),
+	}
+	w := NewWorld(lights, testObjects)
+	r := ray.NewRay(base.NewPoint(0, 0, -5), base.NewVector(0, 0, 1))
+	color := w.ColorAt(r, remainingReflections)
+	g.Expect(color).To(Equal(image.NewColor(0.08000000000000002, 0.1, 0.06)))
+
+	// light pointing at the hit matches a point light
+	lights = []Light{
+		NewSpotLight(base.NewPoint(-10, 10, -10), base.NewVector(10, -10, 9), math.Pi/6, math.Pi/4, image.White),
+	}
+	w = NewWorld(lights, testObjects)
+	color = w.ColorAt(r, remainingReflections)
+	g.Expect(color).To(Equal(image.NewColor(0.38066119308103435, 0.47582649135129296, 0.28549589481077575)))
+}
@@ -2,8 +2,8 @@ package scene
 
 import (
 	"math"
+	"math/rand/v2"
 	"slices"
-	"sync"
 
 	"github.com/sjberman/golang-ray-tracer/pkg/base"
 	"github.com/sjberman/golang-ray-tracer/pkg/image"
@@ -11,72 +11,214 @@ import (
 	"github.com/sjberman/golang-ray-tracer/pkg/scene/ray"
 )
 
-// The total number of recursive reflection traces allowed.
+// The total number of recursive reflection traces allowed, if the world has no max depth.
 const remainingReflections = 4
 
+// Integrators for finding the color of the rays sent by the camera.
+const (
+	WhittedIntegrator = "whitted" // direct lighting, with recursive reflection and refraction
+	PathIntegrator    = "path"    // Monte Carlo path tracing, with light bouncing between surfaces
+)
+
+// Passes that the camera's rays can render.
+const (
+	ShadedPass    = "shaded"    // the lit color of the scene
+	OcclusionPass = "occlusion" // the ambient occlusion of the scene in gray, for compositing
+)
+
 // World represents the collection of all objects in a scene.
 type World struct {
-	lights  []*PointLight
-	objects []object.Object
+	lights     []Light
+	objects    []object.Object
+	integrator string
+	// the most times a ray can be reflected or refracted (or bounce, in a path), where 0 uses the
+	// integrator's default
+	maxDepth int
+	// the smallest fraction of a pixel's color that a reflected or refracted ray is traced for
+	minContribution float64
+	// the number of rays sent from each hit for ambient occlusion (0 for none), and the distance
+	// that objects occlude the hit from
+	occlusionSamples int
+	occlusionRadius  float64
+	pass             string
+	// the color of rays that miss every object, by their direction (nil for black), and whether
+	// it lights diffuse surfaces
+	background         image.Pattern
+	backgroundLighting bool
+	// the density and color of the fog filling the world (0 density for none), and the number of
+	// points along each ray that light scattered by the fog is gathered at (0 for none)
+	fogDensity    float64
+	fogColor      *image.Color
+	fogScattering int
 }
 
-// NewWorld returns a new World object.
-func NewWorld(lights []*PointLight, objects []object.Object) *World {
+// NewWorld returns a new World object, which uses the Whitted integrator to render the shaded pass.
+func NewWorld(lights []Light, objects []object.Object) *World {
 	return &World{
-		lights:  lights,
-		objects: objects,
+		lights:          lights,
+		objects:         objects,
+		integrator:      WhittedIntegrator,
+		occlusionRadius: defaultOcclusionRadius,
+		pass:            ShadedPass,
+	}
+}
+
+// SetIntegrator sets how the color of each ray sent by the camera is found. The path integrator
+// is noisy, and needs many samples per pixel (or passes of a progressive render).
+func (w *World) SetIntegrator(integrator string) {
+	w.integrator = integrator
+}
+
+// SetMaxDepth sets the most times a ray can be reflected or refracted by the Whitted integrator,
+// or the most bounces of a path. Values less than 1 use the integrator's default (4 for Whitted,
+// and 64 for path tracing, which usually ends paths well before then).
+func (w *World) SetMaxDepth(depth int) {
+	w.maxDepth = max(depth, 0)
+}
+
+// SetMinContribution sets the smallest fraction (from 0 to 1) of a pixel's color that a reflected or
+// refracted ray of the Whitted integrator is traced for. Each ray carries the fraction of the pixel
+// it contributes to, which shrinks with every reflective or transparent surface it passes, so rays
+// that would barely change the pixel aren't traced. The path integrator uses Russian roulette instead.
+func (w *World) SetMinContribution(threshold float64) {
+	w.minContribution = max(threshold, 0)
+}
+
+// SetAmbientOcclusion scales the ambient term of each hit by the fraction of the hemisphere above it
+// that isn't blocked by an object within the radius, found by sending the number of sample rays.
+// This darkens creases and the points where objects meet. Fewer than 1 sample turns it off, and a
+// radius of 0 or less uses the default of 1. The path integrator has no ambient term to scale.
+func (w *World) SetAmbientOcclusion(samples int, radius float64) {
+	w.occlusionSamples = max(samples, 0)
+	w.occlusionRadius = radius
+	if radius <= 0 {
+		w.occlusionRadius = defaultOcclusionRadius
+	}
+}
+
+// SetPass sets what the camera's rays render. The occlusion pass uses the world's ambient occlusion
+// settings, or 16 samples if it has none.
+func (w *World) SetPass(pass string) {
+	w.pass = pass
+}
+
+// SetBackground sets the color of rays that miss every object, which is the background's color
+// for the direction of the ray. The background's transform rotates it around the scene. A nil
+// background is black. If lighting is true, the path integrator also lights diffuse surfaces with the
+// background, as if it were light from a sky all around the scene. Otherwise, it's only seen directly,
+// and in reflections and refractions.
+func (w *World) SetBackground(background image.Pattern, lighting bool) {
+	w.background = background
+	w.backgroundLighting = lighting
+}
+
+// returns the background color in the direction of a ray that missed every object.
+func (w *World) backgroundAt(r *ray.Ray) *image.Color {
+	if w.background == nil {
+		return image.Black
 	}
+	direction := w.background.GetTransform().Inverse().MultiplyTuple(r.Direction)
+
+	return w.background.PatternAt(direction.Normalize())
+}
+
+// SetFog fills the world with a uniform fog of the density and color, which fades the light along each
+// ray to the fog's color with distance, where e^(-density * distance) of the light passes through.
+// Rays that miss every object see only the fog. The fog doesn't fade the light reaching surfaces from
+// the world's lights. A density of 0 or less has no fog.
+func (w *World) SetFog(density float64, color *image.Color) {
+	w.fogDensity = max(density, 0)
+	w.fogColor = color
+}
+
+// SetFogScattering sets the number of points along each ray where the light from the world's lights
+// is gathered and scattered back by the fog, showing shafts of light and the shadows of objects
+// through it. The fog is then only lit by the lights, instead of evenly by its color, which instead
+// tints the scattered light. Fewer than 1 point turns it off.
+func (w *World) SetFogScattering(points int) {
+	w.fogScattering = max(points, 0)
 }
 
 // ColorAt returns the color of a specific ray intersection in the world.
 func (w *World) ColorAt(r *ray.Ray, remaining int) *image.Color {
+	return w.colorAt(r, remaining, 1, nil)
+}
+
+// returns the color of a ray, which contributes the weight (fraction) of the pixel's color. The random
+// number generator places the samples of area lights, or each hit seeds its own if it's nil.
+func (w *World) colorAt(r *ray.Ray, remaining int, weight float64, rng *rand.Rand) *image.Color {
 	intersections := w.intersect(r)
 	hit := object.Hit(intersections)
 	if hit == nil {
-		return image.Black
+		added, transmittance := w.mediumAlong(r, math.Inf(1), nil)
+		if transmittance == 0 {
+			return added
+		}
+
+		return added.Add(w.backgroundAt(r).Multiply(transmittance))
 	}
 	hd := prepareComputations(hit, r, intersections)
+	hd.weight = weight
+	hd.rng = rng
+	added, transmittance := w.mediumAlong(r, hd.value, hd.medium)
 
-	return w.shadeHit(hd, remaining)
+	return added.Add(w.shadeHit(hd, remaining).Multiply(transmittance))
 }
 
 // shadeHit returns the color at the intersection encapsulated by hitData.
 func (w *World) shadeHit(hd *hitData, remaining int) *image.Color {
-	surface := image.Black
-	for _, light := range w.lights {
-		shadowed := w.isShadowed(light, hd.overPoint)
-		surface = surface.Add(lighting(
-			light, hd.object, hd.object.GetMaterial(), hd.point, hd.eyev, hd.normalv, shadowed))
-	}
-	reflected := w.reflectedColor(hd, remaining)
-	refracted := w.refractedColor(hd, remaining)
+	shading := w.occludedMaterial(hd)
+	surface := shaderFor(shading).Shade(w.newHit(hd, shading, remaining))
 
 	material := hd.object.GetMaterial()
+	if material.Emission != nil {
+		// the surface glows the same however it's lit
+		surface = surface.Add(material.Emission)
+	}
 	if material.Reflective > 0 && material.Transparency > 0 {
 		reflectance := schlick(hd)
-		reflect := reflected.Multiply(reflectance)
-		refract := refracted.Multiply(1 - reflectance)
+		// the reflected and refracted rays only carry their share of the hit's weight
+		reflectHit, refractHit := *hd, *hd
+		reflectHit.weight *= reflectance
+		refractHit.weight *= 1 - reflectance
+		reflect := w.reflectedColor(&reflectHit, remaining).Multiply(reflectance)
+		refract := w.refractedColor(&refractHit, remaining).Multiply(1 - reflectance)
 
 		return surface.Add(reflect).Add(refract)
 	}
+	reflected := w.reflectedColor(hd, remaining)
+	refracted := w.refractedColor(hd, remaining)
 
 	return surface.Add(reflected).Add(refracted)
 }
 
-// isShadowed returns if a point is in a shadow.
-func (w *World) isShadowed(light *PointLight, point *base.Tuple) bool {
-	v := light.position.Subtract(point)
-	distance := v.Magnitude()
-	direction := v.Normalize()
-
-	ray := ray.NewRay(point, direction)
-	ints := w.intersect(ray)
-	hit := object.Hit(ints)
-	if hit != nil && hit.Value < distance && hit.Object.GetMaterial().Shadow {
-		return true
+// shadowAt returns the fraction of each color of the light sample that reaches the point at the
+// time. An opaque object between the point and the light blocks it, and each transparent surface
+// that the light passes through filters it by the surface's transparency and color. Objects that
+// don't cast a shadow are ignored.
+func (w *World) shadowAt(point *base.Tuple, time float64, sample lightSample) *image.Color {
+	ray := ray.NewRay(point, sample.direction)
+	ray.Time = time
+	transmitted := image.White
+	for _, i := range w.intersect(ray) {
+		if i.Value <= 0 {
+			continue
+		}
+		if i.Value >= sample.distance {
+			break
+		}
+		material := i.Object.GetMaterial()
+		if !material.Shadow {
+			continue
+		}
+		if material.Transparency == 0 {
+			return image.Black
+		}
+		color := surfaceColor(i.Object, material, ray.Position(i.Value), time)
+		transmitted = transmitted.MultiplyColor(color.Multiply(material.Transparency))
 	}
 
-	return false
+	return transmitted
 }
 
 // intersect returns all the intersections between a ray and the objects in the world.
@@ -85,30 +227,50 @@ func (w *World) intersect(r *ray.Ray) []*object.Intersection {
 	for _, o := range w.objects {
 		ints = append(ints, o.Intersect(r)...)
 	}
+	for _, i := range ints {
+		i.Time = r.Time
+	}
 
 	return object.Intersections(ints...)
 }
 
 // reflectedColor returns the color from a reflected ray.
 func (w *World) reflectedColor(hd *hitData, remaining int) *image.Color {
-	if remaining < 1 || hd.object.GetMaterial().Reflective == 0 {
+	reflective := hd.object.GetMaterial().Reflective
+	if remaining < 1 || reflective == 0 || hd.weight*reflective < w.minContribution {
 		return image.Black
 	}
 
 	remaining--
 	reflectRay := ray.NewRay(hd.overPoint, hd.reflectv)
-	color := w.ColorAt(reflectRay, remaining)
+	reflectRay.Time = hd.time
+	color := w.colorAt(reflectRay, remaining, hd.weight*reflective, hd.rng)
 
 	return color.Multiply(hd.object.GetMaterial().Reflective)
 }
 
 // refractedColor returns the color from a refracted ray.
 func (w *World) refractedColor(hd *hitData, remaining int) *image.Color {
-	if remaining < 1 || hd.object.GetMaterial().Transparency == 0 {
+	transparency := hd.object.GetMaterial().Transparency
+	if remaining < 1 || transparency == 0 || hd.weight*transparency < w.minContribution {
 		return image.Black
 	}
 
 	remaining--
+	direction := refractionDirection(hd)
+	if direction == nil {
+		// total internal reflection
+		return image.Black
+	}
+	refractRay := ray.NewRay(hd.underPoint, direction)
+	refractRay.Time = hd.time
+	color := w.colorAt(refractRay, remaining, hd.weight*transparency, hd.rng)
+
+	return color.Multiply(hd.object.GetMaterial().Transparency)
+}
+
+// returns the direction of the refracted ray at the hit, or nil if there is total internal reflection.
+func refractionDirection(hd *hitData) *base.Tuple {
 	// find the ratio of first index of refraction to the second (inversion of Snell's Law)
 	nRatio := hd.n1 / hd.n2
 	// cos(theta_i) is the same as the dot product of the two vectors
@@ -116,22 +278,18 @@ func (w *World) refractedColor(hd *hitData, remaining int) *image.Color {
 	// find sin(theta_t)^2 via trig identity
 	sin2t := nRatio * nRatio * (1 - cosI*cosI)
 	if sin2t > 1 {
-		// total internal reflection
-		return image.Black
+		return nil
 	}
 	// find cos(theta_t) via trig identity
 	cosT := math.Sqrt(1 - sin2t)
-	// compute direction of refracted ray
-	direction := hd.normalv.Multiply((nRatio*cosI - cosT)).Subtract(hd.eyev.Multiply(nRatio))
-	refractRay := ray.NewRay(hd.underPoint, direction)
-	color := w.ColorAt(refractRay, remaining)
 
-	return color.Multiply(hd.object.GetMaterial().Transparency)
+	return hd.normalv.Multiply((nRatio*cosI - cosT)).Subtract(hd.eyev.Multiply(nRatio))
 }
 
 // hitData contains information about a hit intersection.
 type hitData struct {
 	value      float64
+	time       float64
 	object     object.Object
 	point      *base.Tuple
 	overPoint  *base.Tuple
@@ -139,8 +297,11 @@ type hitData struct {
 	eyev       *base.Tuple
 	normalv    *base.Tuple
 	reflectv   *base.Tuple
-	n1, n2     float64 // refractive index for source/dest of ray
+	n1, n2     float64       // refractive index for source/dest of ray
+	medium     object.Object // object the ray travels through to reach the hit, nil if outside of any
 	inside     bool
+	weight     float64    // fraction of the pixel's color that the hit contributes
+	rng        *rand.Rand // places the samples of area lights, nil to seed from the point
 }
 
 // Uses an intersection and ray to build up the hit data.
@@ -151,8 +312,10 @@ func prepareComputations(
 ) *hitData {
 	hd := &hitData{
 		value:  intersection.Value,
+		time:   ray.Time,
 		object: intersection.Object,
 		eyev:   ray.Direction.Negate(),
+		weight: 1,
 	}
 	hd.point = ray.Position(hd.value)
 	hd.normalv = hd.object.NormalAt(hd.point, intersection)
@@ -178,7 +341,8 @@ func prepareComputations(
 			if len(containers) == 0 {
 				hd.n1 = 1
 			} else {
-				hd.n1 = containers[len(containers)-1].GetMaterial().RefractiveIndex
+				hd.medium = containers[len(containers)-1]
+				hd.n1 = hd.medium.GetMaterial().RefractiveIndex
 			}
 		}
 
@@ -224,24 +388,52 @@ func schlick(hd *hitData) float64 {
 	return r0 + (1-r0)*math.Pow(1-cos, 5)
 }
 
-func Render(c *Camera, w *World) *image.Canvas {
-	canvas := image.NewCanvas(c.hsize, c.vsize)
+// returns the average color of the rays sent through a pixel by the camera.
+func (w *World) colorAtPixel(c *Camera, x, y int) *image.Color {
+	samples := c.pixelSamples(x, y)
+	color := image.Black
+	for _, sample := range samples {
+		color = color.Add(w.colorAtSample(c, x, y, sample))
+	}
 
-	for y := range c.vsize - 1 {
-		var wg sync.WaitGroup
-		wg.Add(c.hsize - 1)
+	return color.Multiply(1 / float64(len(samples)))
+}
 
-		for x := range c.hsize - 1 {
-			go func(x, y int) {
-				defer wg.Done()
+// returns the color of the ray sent through the sample of a pixel by the camera,
+// or black if the camera's projection doesn't cover the sample.
+func (w *World) colorAtSample(c *Camera, x, y int, sample pixelSample) *image.Color {
+	ray := c.rayForPixelSample(x, y, sample)
+	if ray == nil {
+		return image.Black
+	}
 
-				ray := c.RayForPixel(x, y)
-				color := w.ColorAt(ray, remainingReflections)
-				canvas.WritePixel(x, y, color)
-			}(x, y)
-		}
-		wg.Wait()
+	if w.pass == OcclusionPass {
+		return w.occlusionColorAt(ray)
+	}
+	// the random choices (such as the paths, and the samples of area lights) are seeded by the pixel
+	// and sample, so that renders are repeatable
+	rng := rand.New(rand.NewPCG(uint64(x)<<32|uint64(y), math.Float64bits(sample.x)^math.Float64bits(sample.y)<<1))
+	if w.integrator == PathIntegrator {
+		return w.pathColorAt(ray, rng)
+	}
+
+	return w.colorAt(ray, w.depth(remainingReflections), 1, rng)
+}
+
+// returns a random number generator seeded by the point, for the random choices at a point that isn't
+// part of a pixel's sample, so that they are repeatable.
+func pointRand(point *base.Tuple) *rand.Rand {
+	return rand.New(rand.NewPCG(
+		math.Float64bits(point.GetX())^math.Float64bits(point.GetY())<<1,
+		math.Float64bits(point.GetZ()),
+	))
+}
+
+// returns the max depth of rays, or the integrator's default if none was set.
+func (w *World) depth(defaultDepth int) int {
+	if w.maxDepth > 0 {
+		return w.maxDepth
 	}
 
-	return canvas
+	return defaultDepth
 }
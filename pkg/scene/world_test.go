@@ -14,7 +14,7 @@ import (
 )
 
 var (
-	testLights  = []*PointLight{NewPointLight(base.NewPoint(-10, 10, -10), image.White)}
+	testLights  = []Light{NewPointLight(base.NewPoint(-10, 10, -10), image.White)}
 	testObjects []object.Object
 	setupOnce   sync.Once
 )
@@ -84,6 +84,121 @@ func TestColorAt(t *testing.T) {
 	}).ShouldNot(BeNil())
 }
 
+func TestColorAt_Motion(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	s := object.NewSphere()
+	s.SetEndTransform(base.Translate(0, 5, 0))
+	w := NewWorld(testLights, []object.Object{s})
+
+	// the sphere moves out of the way of the ray
+	r := ray.NewRay(base.NewPoint(0, 0, -5), base.NewVector(0, 0, 1))
+	g.Expect(w.ColorAt(r, remainingReflections)).ToNot(Equal(image.Black))
+	r.Time = 1
+	g.Expect(w.ColorAt(r, remainingReflections)).To(Equal(image.Black))
+
+	// the sphere moves into the path of the ray, and the hit is shaded where the sphere is
+	r = ray.NewRay(base.NewPoint(0, 5, -5), base.NewVector(0, 0, 1))
+	r.Time = 1
+	ints := w.intersect(r)
+	g.Expect(ints).To(HaveLen(2))
+	g.Expect(ints[0].Time).To(Equal(1.0))
+	hd := prepareComputations(ints[0], r, ints)
+	g.Expect(hd.time).To(Equal(1.0))
+	g.Expect(hd.normalv).To(Equal(base.NewVector(0, 0, -1)))
+
+	// an orbiting sphere is shaded along its arc, not the line between its start and end
+	s = object.NewSphere()
+	s.SetTransform(base.RotateY(0), base.Translate(2, 0, 0))
+	s.SetEndTransform(base.RotateY(math.Pi), base.Translate(2, 0, 0))
+	w = NewWorld(testLights, []object.Object{s})
+	r = ray.NewRay(base.NewPoint(0, 0, -5), base.NewVector(0, 0, 1))
+	r.Time = 0.5
+	ints = w.intersect(r)
+	g.Expect(ints).To(HaveLen(2))
+	g.Expect(math.Abs(ints[0].Value - 4)).To(BeNumerically("~", 2, base.Epsilon))
+	g.Expect(w.ColorAt(r, remainingReflections)).ToNot(Equal(image.Black))
+}
+
+func TestColorAt_Limits(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// a camera between two mirrors, looking at the upper one
+	lower := object.NewPlane()
+	lower.Reflective = 1
+	lower.SetTransform(base.Translate(0, -1, 0))
+	upper := object.NewPlane()
+	upper.Reflective = 1
+	upper.SetTransform(base.Translate(0, 1, 0))
+	w := NewWorld(testLights, []object.Object{lower, upper})
+	c := NewCamera(1, 1, math.Pi/2)
+	c.SetTransform(base.ViewTransform(base.Origin, base.NewPoint(0, 1, 0), base.NewVector(0, 0, 1)))
+	r := c.RayForPixel(0, 0)
+
+	// each reflection adds the ambient light of another mirror
+	g.Expect(w.depth(remainingReflections)).To(Equal(remainingReflections))
+	g.Expect(w.colorAtPixel(c, 0, 0)).To(Equal(w.ColorAt(r, remainingReflections)))
+	w.SetMaxDepth(8)
+	g.Expect(w.depth(remainingReflections)).To(Equal(8))
+	g.Expect(w.colorAtPixel(c, 0, 0)).To(Equal(w.ColorAt(r, 8)))
+	g.Expect(w.colorAtPixel(c, 0, 0).Luminance()).To(BeNumerically(">", w.ColorAt(r, remainingReflections).Luminance()))
+	w.SetMaxDepth(0)
+	g.Expect(w.depth(remainingReflections)).To(Equal(remainingReflections))
+
+	// a reflection that contributes less than the minimum isn't traced
+	upper.Reflective = 0.5
+	lower.Reflective = 0.5
+	full := w.ColorAt(r, remainingReflections)
+	w.SetMinContribution(0.2)
+	// only the first two reflections (contributing 0.5 and 0.25) are traced
+	g.Expect(w.ColorAt(r, remainingReflections)).To(Equal(w.ColorAt(r, 2)))
+	g.Expect(w.ColorAt(r, remainingReflections).Luminance()).To(BeNumerically("<", full.Luminance()))
+	w.SetMinContribution(0)
+	g.Expect(w.ColorAt(r, remainingReflections)).To(Equal(full))
+
+	// a hit that contributes less carries less weight to its reflection
+	ints := w.intersect(r)
+	hd := prepareComputations(object.Hit(ints), r, ints)
+	g.Expect(hd.weight).To(Equal(1.0))
+	w.SetMinContribution(0.3)
+	g.Expect(w.reflectedColor(hd, 1)).ToNot(Equal(image.Black))
+	hd.weight = 0.5
+	g.Expect(w.reflectedColor(hd, 1)).To(Equal(image.Black))
+}
+
+func TestColorAt_Background(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	mirror := object.NewPlane()
+	mirror.Ambient = 0
+	mirror.Diffuse = 0
+	mirror.Specular = 0
+	mirror.Reflective = 1
+	w := NewWorld(testLights, []object.Object{mirror})
+	sky := NewGradientBackground(image.Black, image.NewColor(0, 0, 1))
+	w.SetBackground(sky, false)
+
+	// a ray that misses sees the background in its direction
+	up := ray.NewRay(base.NewPoint(0, 1, 0), base.NewVector(0, 1, 0))
+	g.Expect(w.ColorAt(up, remainingReflections).Equals(image.NewColor(0, 0, 1))).To(BeTrue())
+	level := ray.NewRay(base.NewPoint(0, 1, 0), base.NewVector(0, 0, 2))
+	g.Expect(w.ColorAt(level, remainingReflections).Equals(image.NewColor(0, 0, 0.5))).To(BeTrue())
+
+	// the mirror reflects the sky
+	down := ray.NewRay(base.NewPoint(0, 1, 0), base.NewVector(0, -1, 0))
+	g.Expect(w.ColorAt(down, remainingReflections).Equals(image.NewColor(0, 0, 1))).To(BeTrue())
+
+	// the background's transform rotates it
+	sky.SetTransform(base.RotateX(math.Pi))
+	g.Expect(w.ColorAt(up, remainingReflections)).To(Equal(image.Black))
+
+	w.SetBackground(nil, false)
+	g.Expect(w.ColorAt(level, remainingReflections)).To(Equal(image.Black))
+}
+
 func TestShadeHit(t *testing.T) {
 	t.Parallel()
 	g := NewWithT(t)
@@ -104,10 +219,11 @@ func TestShadeHit(t *testing.T) {
 	ints := object.Intersections(object.NewIntersection(math.Sqrt(2), floor))
 	hd := prepareComputations(ints[0], ray, ints)
 	color := w.shadeHit(hd, 5)
-	g.Expect(color).To(Equal(image.NewColor(0.9364253889815014, 0.6864253889815014, 0.6864253889815014)))
+	// half the light reaches the ball through the floor
+	g.Expect(color).To(Equal(image.NewColor(1.1254657420837892, 0.6864253889815014, 0.6864253889815014)))
 }
 
-func TestIsShadowed(t *testing.T) {
+func TestShadowAt(t *testing.T) {
 	t.Parallel()
 	worldTestSetup()
 
@@ -144,7 +260,8 @@ func TestIsShadowed(t *testing.T) {
 			t.Parallel()
 			g := NewWithT(t)
 
-			g.Expect(w.isShadowed(w.lights[0], test.point)).To(Equal(test.inShadow))
+			shadow := w.shadowAt(test.point, 0, newLightSample(base.NewPoint(-10, 10, -10), test.point))
+			g.Expect(shadow.Equals(image.Black)).To(Equal(test.inShadow))
 		})
 	}
 }
@@ -417,3 +534,107 @@ func TestRender(t *testing.T) {
 	expColor := image.NewColor(0.38066119308103435, 0.47582649135129296, 0.28549589481077575)
 	g.Expect(canvas.PixelAt(5, 5)).To(Equal(expColor))
 }
+
+func TestColorAtPixel(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	worldTestSetup()
+
+	w := NewWorld(testLights, testObjects)
+	c := NewCamera(11, 11, math.Pi/2)
+	c.SetTransform(base.ViewTransform(base.NewPoint(0, 0, -5), base.Origin, base.NewVector(0, 1, 0)))
+
+	// single sample matches the ray through the center of the pixel
+	g.Expect(w.colorAtPixel(c, 2, 3)).To(Equal(w.ColorAt(c.RayForPixel(2, 3), remainingReflections)))
+
+	// multiple samples are averaged
+	c.SetSampling(4, GridSampling)
+	expColor := image.Black
+	for _, sample := range []pixelSample{{x: 0.25, y: 0.25}, {x: 0.75, y: 0.25}, {x: 0.25, y: 0.75}, {x: 0.75, y: 0.75}} {
+		expColor = expColor.Add(w.ColorAt(c.rayForPixelSample(2, 3, sample), remainingReflections))
+	}
+	g.Expect(w.colorAtPixel(c, 2, 3)).To(Equal(expColor.Multiply(0.25)))
+}
+
+func TestRenderAdaptive(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	worldTestSetup()
+
+	w := NewWorld(testLights, testObjects)
+	c := NewCamera(11, 11, math.Pi/2)
+	c.SetTransform(base.ViewTransform(base.NewPoint(0, 0, -5), base.Origin, base.NewVector(0, 1, 0)))
+	c.SetSampling(4, GridSampling)
+
+	// threshold too high to refine any pixels
+	var progress []Progress
+	opts := RenderOptions{Progress: func(p Progress) { progress = append(progress, p) }}
+	canvas, refined := RenderAdaptive(c, w, 10, opts)
+	g.Expect(refined).To(BeZero())
+	// each tile is counted for both passes
+	g.Expect(progress).To(HaveLen(2))
+	g.Expect(progress[1].TilesDone).To(Equal(2))
+	g.Expect(progress[1].TotalTiles).To(Equal(2))
+	for y := range c.vsize {
+		for x := range c.hsize {
+			g.Expect(canvas.PixelAt(x, y)).To(Equal(w.ColorAt(c.RayForPixel(x, y), remainingReflections)))
+		}
+	}
+
+	// only the high contrast pixels are refined
+	canvas, refined = RenderAdaptive(c, w, 0.1, RenderOptions{})
+	g.Expect(refined).To(BeNumerically(">", 0))
+	g.Expect(refined).To(BeNumerically("<", c.hsize*c.vsize))
+	// background corner is flat
+	g.Expect(canvas.PixelAt(0, 0)).To(Equal(image.Black))
+
+	// refined pixels are supersampled
+	full := Render(c, w)
+	refinedCount := 0
+	for y := range c.vsize - 1 {
+		for x := range c.hsize - 1 {
+			if !canvas.PixelAt(x, y).Equals(w.ColorAt(c.RayForPixel(x, y), remainingReflections)) {
+				refinedCount++
+				g.Expect(canvas.PixelAt(x, y)).To(Equal(full.PixelAt(x, y)))
+			}
+		}
+	}
+	g.Expect(refinedCount).To(BeNumerically(">", 0))
+
+	// only pixels in the crop are refined, and the edges of the crop aren't high contrast
+	crop := Crop{X0: 2, Y0: 2, X1: 9, Y1: 9}
+	cropped, croppedRefined := RenderAdaptive(c, w, 0.1, RenderOptions{Crop: crop})
+	g.Expect(croppedRefined).To(BeNumerically("<=", refined))
+	g.Expect(cropped.PixelAt(1, 5)).To(Equal(image.Black))
+	g.Expect(cropped.Crop(3, 3, 8, 8)).To(Equal(canvas.Crop(3, 3, 8, 8)))
+}
+
+func TestShadowAt_Transparent(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	glass := object.NewSphere()
+	glass.Color = image.NewColor(1, 0.5, 0)
+	glass.Transparency = 0.8
+	w := NewWorld(nil, []object.Object{glass})
+	point := base.NewPoint(0, -5, 0)
+	sample := newLightSample(base.NewPoint(0, 5, 0), point)
+
+	// the light is tinted by the sphere's color and transparency at both of its surfaces
+	g.Expect(w.shadowAt(point, 0, sample).Equals(image.NewColor(0.64, 0.16, 0))).To(BeTrue())
+
+	// an object that doesn't cast a shadow lets all the light through
+	glass.Shadow = false
+	g.Expect(w.shadowAt(point, 0, sample)).To(Equal(image.White))
+
+	// an opaque object behind it still blocks the light
+	wall := object.NewCube()
+	wall.SetTransform(base.Translate(0, 2, 0).Multiply(base.Scale(1, 0.1, 1)))
+	w.objects = append(w.objects, wall)
+	g.Expect(w.shadowAt(point, 0, sample)).To(Equal(image.Black))
+	wall.Transparency = 0.5
+	g.Expect(w.shadowAt(point, 0, sample).Equals(image.NewColor(0.25, 0.25, 0.25))).To(BeTrue())
+
+	// nothing past the light casts a shadow
+	g.Expect(w.shadowAt(point, 0, newLightSample(base.NewPoint(0, -2, 0), point))).To(Equal(image.White))
+}
@@ -5,46 +5,103 @@ import (
 	"fmt"
 )
 
+// AreaLight.
+type AreaLight struct {
+	At          []float64 `json:"at"`
+	Attenuation []float64 `json:"attenuation,omitempty"`
+	Intensity   []float64 `json:"intensity"`
+	Jitter      *bool     `json:"jitter,omitempty"`
+	Type        string    `json:"type"`
+	Usteps      int       `json:"usteps"`
+	Uvec        []float64 `json:"uvec"`
+	Vsteps      int       `json:"vsteps"`
+	Vvec        []float64 `json:"vvec"`
+}
+
+// Background.
+type Background struct {
+	Bottom    []float64    `json:"bottom,omitempty"`
+	Color     []float64    `json:"color,omitempty"`
+	File      *string      `json:"file,omitempty"`
+	Lighting  *bool        `json:"lighting,omitempty"`
+	Top       []float64    `json:"top,omitempty"`
+	Transform []*Transform `json:"transform,omitempty"`
+	Type      string       `json:"type"`
+}
+
 // Camera.
 type Camera struct {
-	FieldOfView float64   `json:"fieldOfView"`
-	From        []float64 `json:"from"`
-	Height      int       `json:"height"`
-	To          []float64 `json:"to"`
-	Up          []float64 `json:"up"`
-	Width       int       `json:"width"`
+	AdaptiveThreshold *float64  `json:"adaptiveThreshold,omitempty"`
+	Aperture          *float64  `json:"aperture,omitempty"`
+	ApertureBlades    *int      `json:"apertureBlades,omitempty"`
+	FieldOfView       float64   `json:"fieldOfView,omitempty"`
+	FocalDistance     *float64  `json:"focalDistance,omitempty"`
+	From              []float64 `json:"from"`
+	Height            int       `json:"height"`
+	Projection        *string   `json:"projection,omitempty"`
+	Samples           *int      `json:"samples,omitempty"`
+	Sampling          *string   `json:"sampling,omitempty"`
+	Shutter           []float64 `json:"shutter,omitempty"`
+	To                []float64 `json:"to"`
+	Up                []float64 `json:"up"`
+	ViewWidth         *float64  `json:"viewWidth,omitempty"`
+	Width             int       `json:"width"`
 }
 
 // Csg.
 type Csg struct {
-	LeftChild  ObjectShell  `json:"leftChild"`
-	Material   *Material    `json:"material,omitempty"`
-	Name       string       `json:"name"`
-	Operation  string       `json:"operation"`
-	RightChild ObjectShell  `json:"rightChild"`
-	Transform  []*Transform `json:"transform,omitempty"`
+	EndTransform []*Transform `json:"endTransform,omitempty"`
+	LeftChild    ObjectShell  `json:"leftChild"`
+	Material     *Material    `json:"material,omitempty"`
+	Name         string       `json:"name"`
+	Operation    string       `json:"operation"`
+	RightChild   ObjectShell  `json:"rightChild"`
+	Transform    []*Transform `json:"transform,omitempty"`
+}
+
+// Displacement.
+type Displacement struct {
+	Midlevel     *float64 `json:"midlevel,omitempty"`
+	Pattern      *Pattern `json:"pattern"`
+	Scale        float64  `json:"scale"`
+	Subdivisions *int     `json:"subdivisions,omitempty"`
 }
 
 // File.
 type File struct {
-	File      string       `json:"file"`
-	Material  *Material    `json:"material,omitempty"`
-	Name      string       `json:"name"`
-	Transform []*Transform `json:"transform,omitempty"`
+	Displacement *Displacement `json:"displacement,omitempty"`
+	EndTransform []*Transform  `json:"endTransform,omitempty"`
+	File         string        `json:"file"`
+	Material     *Material     `json:"material,omitempty"`
+	Name         string        `json:"name"`
+	Transform    []*Transform  `json:"transform,omitempty"`
+}
+
+// Fog.
+type Fog struct {
+	Color      []float64 `json:"color"`
+	Density    float64   `json:"density"`
+	Scattering *int      `json:"scattering,omitempty"`
 }
 
 // Group.
 type Group struct {
-	Children  []ObjectShell `json:"children"`
-	Material  *Material     `json:"material,omitempty"`
-	Name      string        `json:"name"`
-	Transform []*Transform  `json:"transform,omitempty"`
+	Children     []ObjectShell `json:"children"`
+	EndTransform []*Transform  `json:"endTransform,omitempty"`
+	Material     *Material     `json:"material,omitempty"`
+	Name         string        `json:"name"`
+	Transform    []*Transform  `json:"transform,omitempty"`
 }
 
 // Light.
 type Light struct {
-	At        []float64 `json:"at"`
-	Intensity []float64 `json:"intensity"`
+	At          []float64 `json:"at,omitempty"`
+	Attenuation []float64 `json:"attenuation,omitempty"`
+	Direction   []float64 `json:"direction,omitempty"`
+	InnerAngle  *float64  `json:"innerAngle,omitempty"`
+	Intensity   []float64 `json:"intensity"`
+	OuterAngle  *float64  `json:"outerAngle,omitempty"`
+	Type        *string   `json:"type,omitempty"`
 }
 
 // Material.
@@ -52,50 +109,75 @@ type Material struct {
 	Ambient         *float64   `json:"ambient,omitempty"`
 	Color           *[]float64 `json:"color,omitempty"`
 	Diffuse         *float64   `json:"diffuse,omitempty"`
+	Emission        *[]float64 `json:"emission,omitempty"`
+	EmissionSamples *int       `json:"emissionSamples,omitempty"`
+	Metallic        *float64   `json:"metallic,omitempty"`
 	Pattern         *Pattern   `json:"pattern,omitempty"`
 	Reflective      *float64   `json:"reflective,omitempty"`
 	RefractiveIndex *float64   `json:"refractiveIndex,omitempty"`
+	Roughness       *float64   `json:"roughness,omitempty"`
+	Shader          *string    `json:"shader,omitempty"`
 	Shadow          *bool      `json:"shadow,omitempty"`
 	Shininess       *float64   `json:"shininess,omitempty"`
 	Specular        *float64   `json:"specular,omitempty"`
 	Transparency    *float64   `json:"transparency,omitempty"`
+	Volume          *Volume    `json:"volume,omitempty"`
 }
 
 // ObjectShell.
 type ObjectShell struct {
-	Material  *Material    `json:"material,omitempty"`
-	Name      string       `json:"name"`
-	Transform []*Transform `json:"transform,omitempty"`
+	EndTransform []*Transform `json:"endTransform,omitempty"`
+	Material     *Material    `json:"material,omitempty"`
+	Name         string       `json:"name"`
+	Transform    []*Transform `json:"transform,omitempty"`
 }
 
 // Pattern.
 type Pattern struct {
-	Color1    []float64    `json:"color1"`
-	Color2    []float64    `json:"color2"`
+	Color1    []float64    `json:"color1,omitempty"`
+	Color2    []float64    `json:"color2,omitempty"`
+	File      *string      `json:"file,omitempty"`
+	Mapping   *string      `json:"mapping,omitempty"`
 	Transform []*Transform `json:"transform,omitempty"`
 	Type      string       `json:"type"`
 }
 
 // RayTracerScene.
 type RayTracerScene struct {
-	Camera *Camera  `json:"camera"`
-	Csgs   []*Csg   `json:"csgs,omitempty"`
-	Files  []*File  `json:"files,omitempty"`
-	Groups []*Group `json:"groups,omitempty"`
-	Lights []*Light `json:"lights"`
-	Shapes []*Shape `json:"shapes,omitempty"`
+	AreaLights []*AreaLight `json:"areaLights,omitempty"`
+	Background *Background  `json:"background,omitempty"`
+	Camera     *Camera      `json:"camera"`
+	Csgs       []*Csg       `json:"csgs,omitempty"`
+	Files      []*File      `json:"files,omitempty"`
+	Fog        *Fog         `json:"fog,omitempty"`
+	Groups     []*Group     `json:"groups,omitempty"`
+	Lights     []*Light     `json:"lights,omitempty"`
+	Render     *Render      `json:"render,omitempty"`
+	Shapes     []*Shape     `json:"shapes,omitempty"`
+}
+
+// Render.
+type Render struct {
+	Integrator       *string  `json:"integrator,omitempty"`
+	MaxDepth         *int     `json:"maxDepth,omitempty"`
+	MinContribution  *float64 `json:"minContribution,omitempty"`
+	OcclusionRadius  *float64 `json:"occlusionRadius,omitempty"`
+	OcclusionSamples *int     `json:"occlusionSamples,omitempty"`
+	Pass             *string  `json:"pass,omitempty"`
 }
 
 // Shape.
 type Shape struct {
-	Closed    *bool        `json:"closed,omitempty"`
-	Inherits  *string      `json:"inherits,omitempty"`
-	Material  *Material    `json:"material,omitempty"`
-	Maximum   *float64     `json:"maximum,omitempty"`
-	Minimum   *float64     `json:"minimum,omitempty"`
-	Name      string       `json:"name"`
-	Transform []*Transform `json:"transform,omitempty"`
-	Type      string       `json:"type"`
+	Closed       *bool         `json:"closed,omitempty"`
+	Displacement *Displacement `json:"displacement,omitempty"`
+	EndTransform []*Transform  `json:"endTransform,omitempty"`
+	Inherits     *string       `json:"inherits,omitempty"`
+	Material     *Material     `json:"material,omitempty"`
+	Maximum      *float64      `json:"maximum,omitempty"`
+	Minimum      *float64      `json:"minimum,omitempty"`
+	Name         string        `json:"name"`
+	Transform    []*Transform  `json:"transform,omitempty"`
+	Type         string        `json:"type"`
 }
 
 // Transform.
@@ -104,6 +186,12 @@ type Transform struct {
 	Values []float64 `json:"values"`
 }
 
+// Volume.
+type Volume struct {
+	Color   []float64 `json:"color"`
+	Density float64   `json:"density"`
+}
+
 func (strct *RayTracerScene) UnmarshalJSON(b []byte) error {
 	var jsonMap map[string]json.RawMessage
 	if err := json.Unmarshal(b, &jsonMap); err != nil {
@@ -112,6 +200,14 @@ func (strct *RayTracerScene) UnmarshalJSON(b []byte) error {
 	// parse all the defined properties
 	for k, v := range jsonMap {
 		switch k {
+		case "areaLights":
+			if err := json.Unmarshal([]byte(v), &strct.AreaLights); err != nil {
+				return fmt.Errorf("error unmarshaling areaLights: %w", err)
+			}
+		case "background":
+			if err := json.Unmarshal([]byte(v), &strct.Background); err != nil {
+				return fmt.Errorf("error unmarshaling background: %w", err)
+			}
 		case "camera":
 			if err := json.Unmarshal([]byte(v), &strct.Camera); err != nil {
 				return fmt.Errorf("error unmarshaling camera: %w", err)
@@ -124,6 +220,10 @@ func (strct *RayTracerScene) UnmarshalJSON(b []byte) error {
 			if err := json.Unmarshal([]byte(v), &strct.Files); err != nil {
 				return fmt.Errorf("error unmarshaling files: %w", err)
 			}
+		case "fog":
+			if err := json.Unmarshal([]byte(v), &strct.Fog); err != nil {
+				return fmt.Errorf("error unmarshaling fog: %w", err)
+			}
 		case "groups":
 			if err := json.Unmarshal([]byte(v), &strct.Groups); err != nil {
 				return fmt.Errorf("error unmarshaling groups: %w", err)
@@ -132,6 +232,10 @@ func (strct *RayTracerScene) UnmarshalJSON(b []byte) error {
 			if err := json.Unmarshal([]byte(v), &strct.Lights); err != nil {
 				return fmt.Errorf("error unmarshaling lights: %w", err)
 			}
+		case "render":
+			if err := json.Unmarshal([]byte(v), &strct.Render); err != nil {
+				return fmt.Errorf("error unmarshaling render: %w", err)
+			}
 		case "shapes":
 			if err := json.Unmarshal([]byte(v), &strct.Shapes); err != nil {
 				return fmt.Errorf("error unmarshaling shapes: %w", err)